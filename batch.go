@@ -0,0 +1,162 @@
+// batch.go - parsing many documents concurrently.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Source is a document to be parsed by ParseAll.
+type Source struct {
+	Name  string // identifies the document, e.g. by its path
+	Input []byte
+}
+
+// Result is the result of parsing a Source with ParseAll.  Index is the
+// position of the Source in the input, counting from zero.  As with Parse,
+// Result may be set even if Err is not nil.
+type Result struct {
+	Index  int
+	Name   string
+	Result *ParseResult
+	Err    error
+}
+
+// BatchError aggregates the errors of a ParseBatch call, in input order.
+type BatchError []Result
+
+// Error stringifies the error per the error interface, with one line per
+// failed document.
+func (e BatchError) Error() string {
+
+	msgs := make([]string, len(e))
+	for i, r := range e {
+		msgs[i] = fmt.Sprintf("%s: %s", r.Name, r.Err)
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// workers returns the number of goroutines to be used for batch work.
+func (p *Parser) workers() int {
+
+	if p.Workers > 0 {
+		return p.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// ParseAll parses the Sources received on inputs concurrently, using at
+// most Workers goroutines, and sends a Result for each of them on the
+// returned channel in order of completion.  The channel is closed when
+// inputs is closed and all Sources are parsed, or when ctx is done; in the
+// latter case, Sources not yet parsed are skipped.
+//
+// An error in one Source never affects the others.
+func (p *Parser) ParseAll(ctx context.Context, inputs <-chan Source) <-chan Result {
+
+	type job struct {
+		index int
+		src   Source
+	}
+	jobs := make(chan job)
+	results := make(chan Result)
+
+	go func() {
+		defer close(jobs)
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case src, ok := <-inputs:
+				if !ok {
+					return
+				}
+				select {
+				case jobs <- job{index: i, src: src}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < p.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if ctx.Err() != nil {
+					continue // drain
+				}
+				res, err := p.Parse(j.src.Input)
+				r := Result{
+					Index:  j.index,
+					Name:   j.src.Name,
+					Result: res,
+					Err:    err,
+				}
+				select {
+				case results <- r:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// ParseBatch parses the sources concurrently as with ParseAll, returning
+// the Results in input order.  If ctx is done before all are parsed, the
+// Results parsed so far are returned together with the context's error.
+// Otherwise, if any Source has an error, all Results are returned together
+// with a BatchError.
+func (p *Parser) ParseBatch(ctx context.Context, sources []Source) ([]Result, error) {
+
+	inputs := make(chan Source)
+	go func() {
+		defer close(inputs)
+		for _, src := range sources {
+			select {
+			case inputs <- src:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	all := make([]*Result, len(sources))
+	for r := range p.ParseAll(ctx, inputs) {
+		r := r
+		all[r.Index] = &r
+	}
+
+	results := []Result{}
+	var errs BatchError
+	for _, r := range all {
+		if r == nil {
+			continue
+		}
+		results = append(results, *r)
+		if r.Err != nil {
+			errs = append(errs, *r)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
@@ -0,0 +1,129 @@
+// batch_test.go
+
+package frostedmd_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func batchSources(n int) []frostedmd.Source {
+
+	sources := make([]frostedmd.Source, n)
+	for i := range sources {
+		input := fmt.Sprintf("# Doc %d\n\n    Index: %d\n\nText.\n", i, i)
+		if i%10 == 3 {
+			input = "# Broken\n\n    Bad: [\n"
+		}
+		sources[i] = frostedmd.Source{
+			Name:  fmt.Sprintf("doc-%d.md", i),
+			Input: []byte(input),
+		}
+	}
+	return sources
+}
+
+func Test_ParseAll(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	parser.Workers = 3
+	inputs := make(chan frostedmd.Source)
+	go func() {
+		for _, src := range batchSources(20) {
+			inputs <- src
+		}
+		close(inputs)
+	}()
+
+	seen := map[int]bool{}
+	errs := 0
+	for r := range parser.ParseAll(context.Background(), inputs) {
+		seen[r.Index] = true
+		assert.Equal(fmt.Sprintf("doc-%d.md", r.Index), r.Name, "name set")
+		assert.NotNil(r.Result, "result set")
+		if r.Err != nil {
+			errs++
+		} else {
+			assert.Equal(r.Index, r.Result.Meta["Index"], "meta for %d",
+				r.Index)
+		}
+	}
+	assert.Equal(20, len(seen), "all sources parsed")
+	assert.Equal(2, errs, "errors returned without aborting")
+
+}
+
+func Test_ParseBatch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	results, err := frostedmd.New().ParseBatch(context.Background(),
+		batchSources(25))
+
+	if assert.Error(err, "error returned") {
+		var be frostedmd.BatchError
+		if assert.True(errors.As(err, &be), "BatchError returned") {
+			assert.Equal(3, len(be), "three errors")
+			assert.Equal(13, be[1].Index, "errors in input order")
+		}
+		assert.Regexp("^doc-3.md: .*\ndoc-13.md: .*\ndoc-23.md: ",
+			err.Error(), "error message lists documents")
+	}
+	if assert.Equal(25, len(results), "all results returned") {
+		for i, r := range results {
+			assert.Equal(i, r.Index, "results in input order")
+		}
+	}
+
+	results, err = frostedmd.New().ParseBatch(context.Background(),
+		batchSources(3))
+	assert.Nil(err, "no error for good sources")
+	assert.Equal(3, len(results), "all results returned")
+
+}
+
+func Test_ParseBatch_Cancel(t *testing.T) {
+
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := frostedmd.New().ParseBatch(ctx, batchSources(100))
+
+	assert.Equal(context.Canceled, err, "context error returned")
+	assert.True(len(results) < 100, "not all sources parsed")
+
+}
+
+func Test_Parser_Concurrent(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	parser.SectionLevel = 1
+	parser.ExtractTables = true
+	var wg sync.WaitGroup
+	titles := make([]interface{}, 50)
+	for i := range titles {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, _ := parser.Parse([]byte(fmt.Sprintf("# T%d\n\nx\n", i)))
+			titles[i] = res.Meta["Title"]
+		}(i)
+	}
+	wg.Wait()
+	for i, title := range titles {
+		assert.Equal(fmt.Sprintf("T%d", i), title, "title %d", i)
+	}
+
+}
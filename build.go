@@ -0,0 +1,501 @@
+// build.go - building a static site from a tree of Markdown files.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IndexTemplate is the html/template used by Builder for the content of
+// generated directory index pages.  Its data is an IndexData.
+var IndexTemplate = template.Must(template.New("index").Parse(
+	`<h1>{{ .Title }}</h1>
+{{- if .Dirs }}
+
+<ul class="dirs">
+{{- range .Dirs }}
+<li><a href="{{ .URL }}">{{ .Title }}</a></li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if .Pages }}
+
+<ul class="pages">
+{{- range .Pages }}
+<li><a href="{{ .URL }}">{{ .Title }}</a></li>
+{{- end }}
+</ul>
+{{- end }}
+`))
+
+// IndexData is the data made available to the IndexTemplate.
+type IndexData struct {
+	Title string
+	Path  string // the directory, "." for the root
+	Dirs  []IndexEntry
+	Pages []IndexEntry
+}
+
+// IndexEntry is a link on an index page, relative to the page itself.
+type IndexEntry struct {
+	Title string
+	URL   string
+	Meta  map[string]interface{} // nil for directories
+}
+
+// Builder renders a tree of Markdown files into a tree of HTML files.
+//
+// Every Markdown file, per the CollectionExtensions, is parsed with the
+// Parser and rendered through the Layout, or if it is nil as a full HTML5
+// document as with RenderDocument; the output file has the extension
+// ".html".  Links to relative ".md" paths are rewritten to ".html".  All
+// other files are copied as-is, except for hidden files.  Includes are only
+// resolved if the Parser's FS is set, normally to the src of the Build.
+//
+// Directories without an index page, such as index.md or index.markdown,
+// are given an index.html listing their pages and subdirectories, rendered
+// with the IndexTemplate and then the Layout as if it were a document with
+// that Content.
+//
+// A Builder remembers the pages of its last Build, so that Rebuild can
+// update only what has changed.  It must not be used concurrently.
+type Builder struct {
+	Parser *Parser
+	Layout *template.Template
+
+	pages map[string]*ParseResult // by source path, from the last build
+}
+
+// BuildReport summarizes a Build.  Errors holds the errors of documents
+// that could not be built, as BuildErrors; these do not stop the build.
+type BuildReport struct {
+	Pages   []string // the output paths, relative to the destination
+	Assets  []string
+	Indexes []string
+	Removed []string // outputs removed by Rebuild
+	Errors  []error
+}
+
+// BuildError describes an error building the page for a source file.
+type BuildError struct {
+	Path string
+	Err  error
+}
+
+// Error stringifies the error per the error interface.
+func (e BuildError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e BuildError) Unwrap() error {
+	return e.Err
+}
+
+// Position returns the path of the file, with the line number of the error
+// if known, in the usual "path:line" form.
+func (e BuildError) Position() string {
+
+	var me MetaError
+	if errors.As(e.Err, &me) && me.Line > 0 {
+		return fmt.Sprintf("%s:%d", e.Path, me.Line)
+	}
+	return e.Path
+}
+
+// NewBuilder returns a Builder with a new Parser.
+func NewBuilder() *Builder {
+	return &Builder{Parser: New()}
+}
+
+// buildTree lists the files and directories in a source tree that are not
+// hidden.
+type buildTree struct {
+	pages  []string // Markdown files
+	assets []string
+	dirs   []string
+}
+
+// walkBuildTree returns the buildTree for src.
+func walkBuildTree(src fs.FS) (*buildTree, error) {
+
+	tree := &buildTree{}
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case name != "." && strings.HasPrefix(d.Name(), "."):
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+		case d.IsDir():
+			tree.dirs = append(tree.dirs, name)
+		case hasExtension(name, CollectionExtensions):
+			tree.pages = append(tree.pages, name)
+		default:
+			tree.assets = append(tree.assets, name)
+		}
+		return nil
+	})
+	return tree, err
+}
+
+// Build builds the tree in src into the directory dst, which is created if
+// necessary.  Errors in individual documents are collected in the report;
+// the error returned is for failures to read the source tree or write the
+// output, which stop the build.
+func (b *Builder) Build(src fs.FS, dst string) (*BuildReport, error) {
+
+	tree, err := walkBuildTree(src)
+	if err != nil {
+		return nil, err
+	}
+	b.pages = map[string]*ParseResult{}
+	report := &BuildReport{}
+	if err := b.buildPages(src, dst, tree.pages, report); err != nil {
+		return report, err
+	}
+	for _, name := range tree.assets {
+		if err := copyFile(src, name, dst); err != nil {
+			return report, err
+		}
+		report.Assets = append(report.Assets, name)
+	}
+	if err := b.buildIndexes(tree, dst, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// Rebuild updates the output of the last Build in dst after the named files
+// have changed in src, whether modified, added or removed.  Only the pages
+// for changed files and for files including them are built again, as are
+// any pages that failed before; outputs of removed files are removed; and
+// the index pages are regenerated.  If there was no previous Build, the
+// whole tree is built.
+func (b *Builder) Rebuild(src fs.FS, dst string, changed []string) (*BuildReport, error) {
+
+	if b.pages == nil {
+		return b.Build(src, dst)
+	}
+	tree, err := walkBuildTree(src)
+	if err != nil {
+		return nil, err
+	}
+	isChanged := map[string]bool{}
+	for _, name := range changed {
+		isChanged[name] = true
+	}
+	report := &BuildReport{}
+
+	present := map[string]bool{}
+	names := []string{}
+	for _, name := range tree.pages {
+		present[name] = true
+		if res := b.pages[name]; res == nil || isChanged[name] ||
+			includesAny(res, isChanged) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range tree.assets {
+		present[name] = true
+	}
+	removed := []string{}
+	for name := range b.pages {
+		if !present[name] {
+			delete(b.pages, name)
+			removed = append(removed, htmlPath(name))
+		}
+	}
+	for _, name := range changed {
+		if !present[name] && !hasExtension(name, CollectionExtensions) {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		err := os.Remove(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return report, err
+		}
+		report.Removed = append(report.Removed, name)
+	}
+
+	if err := b.buildPages(src, dst, names, report); err != nil {
+		return report, err
+	}
+	for _, name := range tree.assets {
+		if !isChanged[name] {
+			continue
+		}
+		if err := copyFile(src, name, dst); err != nil {
+			return report, err
+		}
+		report.Assets = append(report.Assets, name)
+	}
+	if err := b.buildIndexes(tree, dst, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// includesAny returns true if res includes any of the named files.
+func includesAny(res *ParseResult, names map[string]bool) bool {
+
+	for _, name := range res.Includes {
+		if names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// buildPages parses and renders the named Markdown files, recording them
+// in the Builder's pages.  Pages that fail are removed from the pages, but
+// any previous output is left alone.
+func (b *Builder) buildPages(src fs.FS, dst string, names []string, report *BuildReport) error {
+
+	results, errs := b.Parser.parseFiles(src, names)
+	for i, name := range names {
+		if errs[i] != nil {
+			delete(b.pages, name)
+			report.Errors = append(report.Errors,
+				BuildError{Path: name, Err: errs[i]})
+			continue
+		}
+		out := htmlPath(name)
+		content, err := b.Render(results[i])
+		if err != nil {
+			delete(b.pages, name)
+			report.Errors = append(report.Errors,
+				BuildError{Path: name, Err: err})
+			continue
+		}
+		if err := writeFile(dst, out, content); err != nil {
+			return err
+		}
+		b.pages[name] = results[i]
+		report.Pages = append(report.Pages, out)
+	}
+	return nil
+}
+
+// Render returns the page for res: its Content with links rewritten, run
+// through the Layout or wrapped as a document.
+func (b *Builder) Render(res *ParseResult) ([]byte, error) {
+
+	page := *res
+	page.Content = RewriteLinks(res.Content)
+	if b.Layout != nil {
+		return RenderTemplate(b.Layout, &page)
+	}
+	if err := b.Parser.wrapDocument(&page); err != nil {
+		return nil, err
+	}
+	return page.Content, nil
+}
+
+// buildIndexes writes an index page for every directory in the tree lacking
+// one, among those with pages or assets in them.
+func (b *Builder) buildIndexes(tree *buildTree, dst string, report *BuildReport) error {
+
+	docs := []*ParseResult{}
+	for _, res := range b.pages {
+		docs = append(docs, res)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].File.Path < docs[j].File.Path
+	})
+	coll := NewCollection(docs)
+
+	// Only directories with something in them get an index, as do their
+	// parents.
+	built := map[string]bool{}
+	names := append([]string{}, tree.assets...)
+	for _, res := range docs {
+		names = append(names, res.File.Path)
+	}
+	for _, name := range names {
+		for dir := path.Dir(name); !built[dir]; dir = path.Dir(dir) {
+			built[dir] = true
+			if dir == "." {
+				break
+			}
+		}
+	}
+
+	dirs := tree.dirs
+	for _, dir := range dirs {
+		if !built[dir] || hasIndexPage(coll, dir) {
+			continue
+		}
+		data := newIndexData(dir)
+		for _, sub := range dirs {
+			if sub != dir && sub != "." && path.Dir(sub) == dir && built[sub] {
+				data.Dirs = append(data.Dirs, IndexEntry{
+					Title: path.Base(sub),
+					URL:   path.Base(sub) + "/index.html",
+				})
+			}
+		}
+		for _, res := range coll.Docs {
+			if path.Dir(res.File.Path) != dir {
+				continue
+			}
+			data.Pages = append(data.Pages, newPageEntry(res))
+		}
+		out := path.Join(dir, "index.html")
+		page, err := b.RenderIndex(data)
+		if err != nil {
+			report.Errors = append(report.Errors,
+				BuildError{Path: out, Err: err})
+			continue
+		}
+		if err := writeFile(dst, out, page); err != nil {
+			return err
+		}
+		report.Indexes = append(report.Indexes, out)
+	}
+	return nil
+}
+
+// hasIndexPage returns true if the collection has an index document in dir,
+// with any of the CollectionExtensions.
+func hasIndexPage(coll *Collection, dir string) bool {
+
+	for _, ext := range CollectionExtensions {
+		if coll.Get(path.Join(dir, "index"+ext)) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// newIndexData returns the IndexData for dir, without any entries.
+func newIndexData(dir string) *IndexData {
+
+	if dir == "." {
+		return &IndexData{Title: "Index", Path: dir}
+	}
+	return &IndexData{Title: dir, Path: dir}
+}
+
+// newPageEntry returns the IndexEntry for a page in its directory's index.
+func newPageEntry(res *ParseResult) IndexEntry {
+
+	title := html.UnescapeString(metaString(res.Meta, "Title"))
+	if title == "" {
+		title = path.Base(res.File.Path)
+	}
+	return IndexEntry{
+		Title: title,
+		URL:   path.Base(htmlPath(res.File.Path)),
+		Meta:  res.Meta,
+	}
+}
+
+// RenderIndex returns the index page for data, with its Pages sorted by
+// Title, rendered with the IndexTemplate and then as with Render.
+func (b *Builder) RenderIndex(data *IndexData) ([]byte, error) {
+
+	sort.SliceStable(data.Pages, func(i, j int) bool {
+		return data.Pages[i].Title < data.Pages[j].Title
+	})
+	var buf bytes.Buffer
+	if err := IndexTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return b.Render(&ParseResult{
+		Meta:    map[string]interface{}{"Title": data.Title},
+		Content: buf.Bytes(),
+	})
+}
+
+// htmlPath returns the output path for a Markdown file.
+func htmlPath(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name)) + ".html"
+}
+
+// linkRegexp matches href and src attributes in rendered HTML.
+var linkRegexp = regexp.MustCompile(`(href|src)="([^"]*)"`)
+
+// schemeRegexp matches URLs with a scheme, e.g. "https:" or "mailto:".
+var schemeRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// RewriteLinks rewrites links to relative Markdown paths in the HTML,
+// such as "guide.md" or "../intro.md#setup", to the corresponding ".html"
+// paths.  Absolute URLs with a scheme or host are left alone.
+func RewriteLinks(content []byte) []byte {
+	return rewriteLinks(content, ".html")
+}
+
+// rewriteLinks rewrites links as for RewriteLinks, to paths with the
+// extension ext, which may be empty.
+func rewriteLinks(content []byte, ext string) []byte {
+
+	return linkRegexp.ReplaceAllFunc(content, func(m []byte) []byte {
+		parts := linkRegexp.FindSubmatch(m)
+		url := string(parts[2])
+		if schemeRegexp.MatchString(url) || strings.HasPrefix(url, "//") {
+			return m
+		}
+		target, rest := url, ""
+		if i := strings.IndexAny(url, "?#"); i >= 0 {
+			target, rest = url[:i], url[i:]
+		}
+		if !hasExtension(target, CollectionExtensions) {
+			return m
+		}
+		target = strings.TrimSuffix(target, path.Ext(target)) + ext
+		return []byte(fmt.Sprintf(`%s="%s%s"`, parts[1], target, rest))
+	})
+}
+
+// writeFile writes the content to the slash-separated name under dir,
+// creating directories as needed.
+func writeFile(dir, name string, content []byte) error {
+
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, content, 0644)
+}
+
+// copyFile copies the named file from fsys to the same path under dir.
+func copyFile(fsys fs.FS, name, dir string) error {
+
+	in, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,218 @@
+// build_test.go
+
+package frostedmd_test
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+var buildFS = fstest.MapFS{
+	"index.md": {Data: []byte("# Home\n\nSee [the guide](docs/guide.md#setup).\n")},
+	"docs/guide.md": {Data: []byte("# Guide\n\nBack [home](../index.md), " +
+		"or [away](https://example.com/x.md).\n")},
+	"docs/intro.markdown": {Data: []byte("# Intro\n\nHello.\n")},
+	"img/logo.png":        {Data: []byte("PNG")},
+	".hidden/secret.md":   {Data: []byte("# Secret\n")},
+	".gitignore":          {Data: []byte("*.tmp\n")},
+	"bad.md":              {Data: []byte("    foo: [bar\n\nBad meta.\n")},
+}
+
+func readBuilt(t *testing.T, dir, name string) string {
+
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func Test_Builder_Build(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dst := t.TempDir()
+	report, err := frostedmd.NewBuilder().Build(buildFS, dst)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal([]string{"docs/guide.html", "docs/intro.html",
+		"index.html"}, report.Pages, "pages built")
+	assert.Equal([]string{"img/logo.png"}, report.Assets, "assets copied")
+	assert.Equal([]string{"docs/index.html", "img/index.html"},
+		report.Indexes, "indexes built where missing")
+	if assert.Equal(1, len(report.Errors), "one error") {
+		assert.Contains(report.Errors[0].Error(), "bad.md", "error has file")
+	}
+
+	home := readBuilt(t, dst, "index.html")
+	assert.Contains(home, "<!DOCTYPE html>", "page is a document")
+	assert.Contains(home, `href="docs/guide.html#setup"`, "link rewritten")
+	guide := readBuilt(t, dst, "docs/guide.html")
+	assert.Contains(guide, `href="../index.html"`, "parent link rewritten")
+	assert.Contains(guide, `href="https://example.com/x.md"`,
+		"absolute link untouched")
+	assert.Equal("PNG", readBuilt(t, dst, "img/logo.png"), "asset copied")
+
+	index := readBuilt(t, dst, "docs/index.html")
+	assert.Contains(index, `<a href="guide.html">Guide</a>`, "index has guide")
+	assert.Contains(index, `<a href="intro.html">Intro</a>`, "index has intro")
+
+	_, err = os.Stat(filepath.Join(dst, ".hidden"))
+	assert.True(os.IsNotExist(err), "hidden dir skipped")
+	_, err = os.Stat(filepath.Join(dst, ".gitignore"))
+	assert.True(os.IsNotExist(err), "hidden file skipped")
+
+}
+
+func Test_Builder_Build_IndexMarkdown(t *testing.T) {
+
+	assert := assert.New(t)
+
+	fsys := fstest.MapFS{
+		"guide/index.markdown": {Data: []byte("# Guide Home\n")},
+		"guide/setup.md":       {Data: []byte("# Setup\n")},
+	}
+	dst := t.TempDir()
+	report, err := frostedmd.NewBuilder().Build(fsys, dst)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal([]string{"index.html"}, report.Indexes,
+		"no generated index over index.markdown")
+	assert.Contains(readBuilt(t, dst, "guide/index.html"),
+		"<h1>Guide Home</h1>", "index page kept")
+
+}
+
+func Test_Builder_Build_Layout(t *testing.T) {
+
+	assert := assert.New(t)
+
+	builder := frostedmd.NewBuilder()
+	builder.Layout = template.Must(template.New("layout").Parse(
+		`<main title="{{ .Meta.Title }}">{{ .Content }}</main>`))
+	dst := t.TempDir()
+	report, err := builder.Build(fstest.MapFS{
+		"a/page.md": {Data: []byte("# Page\n\nSee [b](../b.md).\n")},
+	}, dst)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Empty(report.Errors, "no document errors")
+	assert.Equal(`<main title="Page"><h1>Page</h1>
+
+<p>See <a href="../b.html">b</a>.</p>
+</main>`, readBuilt(t, dst, "a/page.html"), "page uses layout")
+	assert.Contains(readBuilt(t, dst, "index.html"),
+		`<main title="Index"><h1>Index</h1>`, "root index uses layout")
+	assert.Contains(readBuilt(t, dst, "index.html"),
+		`<a href="a/index.html">a</a>`, "root index links to subdir")
+
+}
+
+func Test_Builder_Build_WriteError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dst := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(dst, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := frostedmd.NewBuilder().Build(buildFS, dst)
+	assert.NotNil(err, "error when output is a file")
+
+}
+
+func Test_Builder_Rebuild(t *testing.T) {
+
+	assert := assert.New(t)
+
+	fsys := fstest.MapFS{
+		"a.md":          {Data: []byte("# A\n\n{{< include \"part.md\" >}}\n")},
+		"b.md":          {Data: []byte("# B\n\nBee.\n")},
+		"part.md":       {Data: []byte("Part one.\n")},
+		"sub/c.md":      {Data: []byte("# C\n\nSee.\n")},
+		"sub/image.png": {Data: []byte("PNG")},
+	}
+	dst := t.TempDir()
+	builder := frostedmd.NewBuilder()
+	builder.Parser.FS = fsys
+
+	// Without a previous build everything is built.
+	report, err := builder.Rebuild(fsys, dst, nil)
+	if !assert.Nil(err, "no error on first build") {
+		return
+	}
+	assert.Equal(4, len(report.Pages), "all pages built")
+
+	// Included files rebuild their includers.
+	fsys["part.md"] = &fstest.MapFile{Data: []byte("Part two.\n")}
+	report, err = builder.Rebuild(fsys, dst, []string{"part.md"})
+	if assert.Nil(err, "no error on include change") {
+		assert.Equal([]string{"a.html", "part.html"}, report.Pages,
+			"includer and include rebuilt")
+		assert.Contains(readBuilt(t, dst, "a.html"), "Part two.",
+			"new content")
+	}
+
+	// Errors keep the old output, and the page is retried next time.
+	fsys["b.md"] = &fstest.MapFile{Data: []byte("# B\n\n    x: [y\n")}
+	report, err = builder.Rebuild(fsys, dst, []string{"b.md"})
+	if assert.Nil(err, "no error on broken page") && assert.Equal(1,
+		len(report.Errors), "one error") {
+		be, ok := report.Errors[0].(frostedmd.BuildError)
+		if assert.True(ok, "error is a BuildError") {
+			assert.Equal("b.md:3", be.Position(), "position with line")
+		}
+		assert.Contains(readBuilt(t, dst, "b.html"), "Bee.", "old output")
+	}
+	fsys["b.md"] = &fstest.MapFile{Data: []byte("# B\n\nFixed.\n")}
+	report, err = builder.Rebuild(fsys, dst, []string{"sub/image.png"})
+	if assert.Nil(err, "no error on retry") {
+		assert.Equal([]string{"b.html"}, report.Pages, "failed page retried")
+		assert.Equal([]string{"sub/image.png"}, report.Assets,
+			"changed asset copied")
+	}
+
+	// Removed files have their output removed, and leave the indexes.
+	delete(fsys, "sub/c.md")
+	delete(fsys, "sub/image.png")
+	report, err = builder.Rebuild(fsys, dst,
+		[]string{"sub/c.md", "sub/image.png"})
+	if assert.Nil(err, "no error on removal") {
+		assert.Equal([]string{"sub/c.html", "sub/image.png"},
+			report.Removed, "outputs removed")
+		_, err = os.Stat(filepath.Join(dst, "sub", "c.html"))
+		assert.True(os.IsNotExist(err), "page gone")
+		assert.NotContains(readBuilt(t, dst, "index.html"), "sub/",
+			"index updated")
+	}
+
+}
+
+func Test_RewriteLinks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for in, exp := range map[string]string{
+		`<a href="a.md">`:              `<a href="a.html">`,
+		`<a href="a/b.markdown?x=1">`:  `<a href="a/b.html?x=1">`,
+		`<img src="pic.md#top">`:       `<img src="pic.html#top">`,
+		`<a href="http://x.com/a.md">`: `<a href="http://x.com/a.md">`,
+		`<a href="//x.com/a.md">`:      `<a href="//x.com/a.md">`,
+		`<a href="a.txt">`:             `<a href="a.txt">`,
+		`<a href="#a.md">`:             `<a href="#a.md">`,
+	} {
+		assert.Equal(exp, string(frostedmd.RewriteLinks([]byte(in))),
+			"rewritten: %s", in)
+	}
+
+}
@@ -0,0 +1,222 @@
+// cache.go - caching parse results on disk.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// cacheVersion is part of every cache key, and must be changed whenever
+// parsing changes in a way that invalidates cached results.
+const cacheVersion = "fmd-cache-1"
+
+func init() {
+	// Meta values are stored as interfaces, and gob needs to know the
+	// types that the JSON and YAML decoders put in them.
+	gob.Register(map[string]interface{}{})
+	gob.Register(map[interface{}]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register(time.Time{})
+}
+
+// Cache stores ParseResults on local disk, so that unchanged inputs need
+// not be parsed again.  It is used by a Parser whose Cache is set, and thus
+// by everything built on Parse: ParseFile, ParseBatch, LoadCollection, the
+// Builder and so on.
+//
+// Results are keyed on a hash of the input and of the Parser settings that
+// affect parsing: extensions, HTML flags, Meta Block position and the like.
+// The files read by include directives are recorded with their own hashes,
+// and a cached result is only used if they are unchanged in the Parser's
+// FS.  Only successful results are cached.
+//
+// Shortcodes, TemplateFuncs and Resolvers other than the SlugResolver are
+// known to the cache only by name or type, and are assumed to behave the
+// same from one run to the next; if that is not the case, Clear the cache.
+//
+// A Cache is safe for concurrent use, including by several processes.
+// Failures to write to the cache are ignored.
+type Cache struct {
+	Dir    string
+	hits   int64
+	misses int64
+}
+
+// CacheStats counts the lookups in a Cache since it was created.
+type CacheStats struct {
+	Hits   int
+	Misses int
+}
+
+// cacheEntry is the form in which a result is stored.
+type cacheEntry struct {
+	Result *ParseResult
+	Deps   map[string]string // included file name to content hash
+}
+
+// NewCache returns a Cache storing its files under dir, which is created
+// when first needed.
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// Stats returns the number of cache hits and misses so far.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:   int(atomic.LoadInt64(&c.hits)),
+		Misses: int(atomic.LoadInt64(&c.misses)),
+	}
+}
+
+// Clear removes all cached results.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
+
+// path returns the file in which the result for key is stored.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".gob")
+}
+
+// get returns the cached result for key, if there is one and the files it
+// included are unchanged in fsys.
+func (c *Cache) get(key string, fsys fs.FS) (*ParseResult, bool) {
+
+	entry, ok := c.read(key)
+	if ok {
+		for name, hash := range entry.Deps {
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil || hashBytes(content) != hash {
+				ok = false
+				break
+			}
+		}
+	}
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddInt64(&c.hits, 1)
+	return entry.Result, true
+}
+
+// read reads the entry for key from disk.
+func (c *Cache) read(key string) (*cacheEntry, bool) {
+
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	entry := &cacheEntry{}
+	if err := gob.NewDecoder(f).Decode(entry); err != nil {
+		return nil, false
+	}
+	if entry.Result == nil {
+		return nil, false
+	}
+	if entry.Result.Meta == nil {
+		entry.Result.Meta = map[string]interface{}{} // gob omits empty maps
+	}
+	return entry, true
+}
+
+// put stores the result for key, with the hashes of the files it included.
+// The file is written in full before being moved into place, so concurrent
+// readers never see a partial entry.
+func (c *Cache) put(key string, res *ParseResult, deps map[string]string) {
+
+	var buf bytes.Buffer
+	entry := &cacheEntry{Result: res, Deps: deps}
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return
+	}
+	file := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// cacheKey returns the cache key for parsing input with the Parser.
+func (p *Parser) cacheKey(input []byte) string {
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%t %d %d %t %d %t\n", cacheVersion,
+		p.MetaAtEnd, p.MarkdownExtensions, p.HTMLFlags,
+		p.ExtractTables, p.SectionLevel, p.ExpandTemplates)
+	fmt.Fprintf(h, "%t %d %d\n", p.FS != nil, p.IncludeMeta,
+		p.MaxIncludeDepth)
+	shortcodes := []string{}
+	for name := range p.Shortcodes {
+		shortcodes = append(shortcodes, name)
+	}
+	funcs := []string{}
+	for name := range p.TemplateFuncs {
+		funcs = append(funcs, name)
+	}
+	sort.Strings(shortcodes)
+	sort.Strings(funcs)
+	fmt.Fprintf(h, "%q %q\n", shortcodes, funcs)
+	if p.WikiLinks {
+		fmt.Fprintf(h, "%s\n", resolverKey(p.Resolver))
+	}
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// resolverKey describes a Resolver for the cache key.  SlugResolvers are
+// described in full, others only by type.
+func resolverKey(r Resolver) string {
+
+	var sr *SlugResolver
+	switch v := r.(type) {
+	case nil:
+		sr = &SlugResolver{}
+	case SlugResolver:
+		sr = &v
+	case *SlugResolver:
+		sr = v
+	default:
+		return fmt.Sprintf("%T", r)
+	}
+	pages := []string{}
+	for page, ok := range sr.Pages {
+		if ok {
+			pages = append(pages, page)
+		}
+	}
+	sort.Strings(pages)
+	return fmt.Sprintf("slug %q %t %q", sr.Prefix, sr.Pages != nil, pages)
+}
+
+// hashBytes returns the hex-encoded SHA-256 hash of b.
+func hashBytes(b []byte) string {
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
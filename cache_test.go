@@ -0,0 +1,138 @@
+// cache_test.go
+
+package frostedmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Cache_Parse(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cache := frostedmd.NewCache(t.TempDir())
+	input := []byte("# Cached\n\n    Tags: [a, b]\n    Nested: {x: 1}\n\nHello.\n")
+
+	parser := frostedmd.New()
+	parser.Cache = cache
+	first, err := parser.Parse(input)
+	if !assert.Nil(err, "no error on first parse") {
+		return
+	}
+	second, err := parser.Parse(input)
+	if !assert.Nil(err, "no error on second parse") {
+		return
+	}
+	assert.Equal(frostedmd.CacheStats{Hits: 1, Misses: 1}, cache.Stats(),
+		"second parse was a hit")
+	assert.Equal(first.Meta, second.Meta, "same meta")
+	assert.Equal(first.Content, second.Content, "same content")
+	assert.Equal(first.Headings, second.Headings, "same headings")
+
+	// Another Parser with the same settings uses the same entries.
+	other := frostedmd.New()
+	other.Cache = frostedmd.NewCache(cache.Dir)
+	_, err = other.Parse(input)
+	assert.Nil(err, "no error with other parser")
+	assert.Equal(1, other.Cache.Stats().Hits, "shared across parsers")
+
+	// Different settings do not.
+	other.SectionLevel = 2
+	_, err = other.Parse(input)
+	assert.Nil(err, "no error with sections")
+	assert.Equal(1, other.Cache.Stats().Misses, "settings change the key")
+
+	assert.Nil(cache.Clear(), "no error clearing")
+	_, err = parser.Parse(input)
+	assert.Nil(err, "no error after clearing")
+	assert.Equal(2, cache.Stats().Misses, "cleared")
+
+}
+
+func Test_Cache_EmptyMeta(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.NewBasic()
+	parser.Cache = frostedmd.NewCache(t.TempDir())
+	parser.Parse([]byte("Just text.\n"))
+	res, err := parser.Parse([]byte("Just text.\n"))
+	if assert.Nil(err, "no error") {
+		assert.Equal(1, parser.Cache.Stats().Hits, "hit")
+		assert.Equal(map[string]interface{}{}, res.Meta, "meta not nil")
+	}
+
+}
+
+func Test_Cache_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	parser.Cache = frostedmd.NewCache(t.TempDir())
+	input := []byte("    foo: [bar\n\nBad meta.\n")
+	for i := 0; i < 2; i++ {
+		_, err := parser.Parse(input)
+		assert.Error(err, "error on parse %d", i)
+	}
+	assert.Equal(frostedmd.CacheStats{Misses: 2}, parser.Cache.Stats(),
+		"errors not cached")
+
+	// A cache that can not be written to does not stop parsing.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	parser.Cache = frostedmd.NewCache(file)
+	res, err := parser.Parse([]byte("Fine.\n"))
+	if assert.Nil(err, "no error with broken cache") {
+		assert.Equal("<p>Fine.</p>\n", string(res.Content), "parsed")
+	}
+
+}
+
+func Test_Cache_Includes(t *testing.T) {
+
+	assert := assert.New(t)
+
+	fsys := fstest.MapFS{
+		"main.md":    {Data: []byte("Main.\n\n{{< include \"part.md\" >}}\n")},
+		"part.md":    {Data: []byte("Part one.\n")},
+		"unused.txt": {Data: []byte("Unused.\n")},
+	}
+	parser := frostedmd.New()
+	parser.FS = fsys
+	parser.Cache = frostedmd.NewCache(t.TempDir())
+
+	res, err := parser.ParseFile(fsys, "main.md")
+	if assert.Nil(err, "no error") {
+		assert.Contains(string(res.Content), "Part one.", "included")
+		assert.Equal("main.md", res.Meta["Path"], "file meta added")
+	}
+
+	fsys["unused.txt"] = &fstest.MapFile{Data: []byte("Changed.\n")}
+	res, err = parser.ParseFile(fsys, "main.md")
+	if assert.Nil(err, "no error") {
+		assert.Equal(1, parser.Cache.Stats().Hits, "unrelated change: hit")
+		assert.Equal("main.md", res.Meta["Path"], "file meta still added")
+	}
+
+	fsys["part.md"] = &fstest.MapFile{Data: []byte("Part two.\n")}
+	res, err = parser.ParseFile(fsys, "main.md")
+	if assert.Nil(err, "no error") {
+		assert.Equal(2, parser.Cache.Stats().Misses, "dependency change: miss")
+		assert.Contains(string(res.Content), "Part two.", "new include")
+	}
+
+	delete(fsys, "part.md")
+	_, err = parser.ParseFile(fsys, "main.md")
+	assert.Error(err, "error for missing dependency")
+
+}
@@ -5,12 +5,19 @@ package frostedmd
 import (
 
 	// Standard library:
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	// Third-Party:
 	"github.com/docopt/docopt-go"
@@ -23,6 +30,11 @@ const (
 	CMD_FILE_ERROR          = 2
 	CMD_PARSE_ERROR         = 3
 	CMD_SERIALIZATION_ERROR = 4
+	CMD_TEMPLATE_ERROR      = 5
+	CMD_NO_MATCH            = 6
+	CMD_SERVER_ERROR        = 7
+	CMD_LINT_ERROR          = 8
+	CMD_LINK_ERROR          = 9
 	CMD_OTHER_ERROR         = 99
 )
 
@@ -34,7 +46,16 @@ var DefaultExitFunction = os.Exit
 var CmdUsage = `
 
 Usage:
-  fmd [options] [FILE]
+  fmd query [options] DIR
+  fmd build [options] SRC DST
+  fmd watch [options] DIR
+  fmd serve [options] DIR
+  fmd server [options]
+  fmd lint [options] PATH...
+  fmd check-links [options] DIR
+  fmd set [options] FILE [ASSIGN...]
+  fmd unset [options] FILE KEY...
+  fmd [options] [FILE...]
   fmd --version
   fmd --license
   fmd -h | --help
@@ -49,16 +70,75 @@ Options:
   -c, --content     Only print the content (as a string), not the meta.
   -m, --meta        Only print the meta block, not the content.
   -p, --plainmd     Convert as "plain" Markdown (not Frosted Markdown).
-  -f, --force       Do not abort on errors (log them to STDERR).
+  -d, --document    Produce a full HTML5 document as the content.
+  -e, --meta-at-end
+                    Expect the meta block at the end of the file, also when
+                    adding one with set.
+  --style=STYLE     Use STYLE for --document: a canned style (default, dark
+                    or none) or the URL of a stylesheet to link.
+  --template=FILES  Render the content through the html/template FILES (a
+                    glob pattern); documents may select one of them by name
+                    with the "Template" meta key.
+  --cache=DIR       Cache parse results in DIR, so that unchanged files are
+                    not parsed again.
+  -f, --force       Do not abort on errors (log them to STDERR); with
+                    several files, summarize the errors at the end.
   -s, --silent      Do not print error messages.
   -t, --test        Parse file but do not print any output on success.
+  -r, --recursive   Parse all Markdown files in the FILE directories.
+  --ndjson          Write output as newline-delimited JSON, one document
+                    per line.
+  --pipe            Read newline-delimited JSON requests from STDIN, and
+                    write a line of JSON for each: {"id", "path"} to parse
+                    a file, or {"id", "source"} to parse the source itself.
+  --multi           Parse multiple documents from the file (as a list).
+  --separator=SEP   Separate --multi documents by lines of SEP (default +++),
+                    or at headings if SEP is a heading marker like "##".
+  --where=EXPR      Only output documents whose meta matches EXPR, for
+                    instance: 'Tags contains "golang" and Date > 2024-01-01'
+  --select=KEYS     Only output the meta KEYS (a comma-separated list).
   --license         Print the software license.
+
+Query options:
+  --tag=TAG         Only documents with TAG in their Tags.
+  --match=KEY=VAL   Only documents whose KEY equals (or lists) VAL.
+  --since=DATE      Only documents with a Date on or after DATE.
+  --until=DATE      Only documents with a Date on or before DATE.
+  --sort=KEY        Sort documents by KEY.
+  --reverse         Reverse the sort order.
+  --group=KEY       Group documents by KEY, or by year for a date KEY:year.
+
+Watch options:
+  --out=DIR         Build the site into DIR, and keep it up to date.
+  --interval=TIME   Poll for changes every TIME, e.g. 250ms (default 500ms).
+
+Server options:
+  --listen=ADDR     Listen on ADDR (default localhost:8080).
+  --max-size=BYTES  Refuse parse requests larger than BYTES (default 1MB).
+
+Lint options:
+  --rules=RULES     Only check the RULES (a comma-separated list) of:
+                    meta-error, parse-error, required-key, duplicate-title,
+                    duplicate-slug, heading-skip, image-alt and empty.
+  --require=KEYS    Require the meta KEYS (a comma-separated list).
+
+Set options:
+  --add-to=KEY=VAL  Add VAL, as a string, to the list at KEY unless it is
+                    already there.
 `
 
+// cmdCommands are the subcommands known to the Cmd.
+var cmdCommands = []string{"query", "build", "watch", "serve", "server",
+	"lint", "check-links", "set", "unset"}
+
 // CmdOptions describes the options available to the command.  The standard
 // fmd command exposes all of them.
 type CmdOptions struct {
 	File          string
+	Files         []string // set instead of File for several files
+	Recursive     bool
+	NDJSON        bool
+	Pipe          bool
 	Format        string
 	Indent        bool
 	NoBase64      bool
@@ -68,6 +148,34 @@ type CmdOptions struct {
 	Force         bool
 	Silent        bool
 	Test          bool
+	Multi         bool
+	Separator     string
+	Document      bool
+	Style         string
+	Template      string
+	Cache         string
+	Command       string // the subcommand, if any
+	Dir           string // the DIR or SRC for subcommands
+	Out           string // the DST or --out for subcommands
+	Interval      time.Duration
+	Listen        string
+	MaxSize       int64
+	Tag           string
+	Match         string
+	Since         string
+	Until         string
+	Sort          string
+	Reverse       bool
+	Group         string
+	Where         string
+	Select        []string
+	Rules         []string
+	Require       []string
+	Edits         []MetaEdit // for the set and unset subcommands
+	Tables        bool       // parser options, set for server requests
+	SectionLevel  int
+	WikiLinks     bool
+	MetaAtEnd     bool // also set by --meta-at-end
 }
 
 // CmdError defines an error in the command-running context.
@@ -89,6 +197,11 @@ func (e CmdError) Error() string {
 
 }
 
+// Unwrap returns the source error.
+func (e CmdError) Unwrap() error {
+	return e.Err
+}
+
 // Cmd defines a command-line program or its equivalent.
 type Cmd struct {
 	Name    string
@@ -96,6 +209,8 @@ type Cmd struct {
 	Usage   string
 	Options *CmdOptions
 	Result  *ParseResult
+	Results []*ParseResult // set instead of Result for multiple results
+	Errors  []error        // errors skipped with the Force option
 
 	// In order to make testing realistically possible in the command context
 	// we make these standard things overrideable:
@@ -121,17 +236,108 @@ func NewCmd(name, version, usage string) *Cmd {
 }
 
 // Run calls the methods used for a standard command run in order: SetOptions,
-// ParseFile, and finally PrintResult.  The first error encountered is
-// returned, to (normally) be passed to Fail.  Note that in the interest
+// ParseFile, FilterResults, and finally PrintResult.  The first error
+// encountered is returned, to (normally) be passed to Fail.  Note that in the interest
 // of simplicity, docopt is allowed to exit directly from within SetOptions.
+//
+// If the Options specify a subcommand, its method is run instead of
+// ParseFile and PrintResult.
 func (c *Cmd) Run() error {
 	if err := c.SetOptions(); err != nil {
 		return err
 	}
-	if err := c.ParseFile(); err != nil {
+	switch c.Options.Command {
+	case "query":
+		return c.Query()
+	case "build":
+		return c.Build()
+	case "watch":
+		return c.Watch(context.Background())
+	case "serve":
+		return c.Serve(context.Background())
+	case "server":
+		return c.Server(context.Background())
+	case "lint":
+		return c.Lint()
+	case "check-links":
+		return c.CheckLinks()
+	case "set", "unset":
+		return c.EditMeta()
+	}
+	if c.Options.Pipe {
+		return c.Pipe()
+	}
+	parse := c.ParseFile
+	if c.Options.Files != nil {
+		parse = c.ParseFiles
+	}
+	if err := parse(); err != nil {
+		return err
+	}
+	matched, err := c.FilterResults()
+	if err != nil {
+		return err
+	}
+	if err := c.PrintResult(); err != nil {
 		return err
 	}
-	return c.PrintResult()
+	if !matched {
+		return noMatchError()
+	}
+	return c.summarizeErrors()
+}
+
+// commaList returns the items of a comma-separated option value, or nil if
+// it is not set.
+func commaList(value interface{}) []string {
+
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
+
+// noMatchError returns the (silent) error for a Where option that matched
+// no documents.
+func noMatchError() error {
+	return CmdError{
+		Code:   CMD_NO_MATCH,
+		Err:    errors.New("No documents matched."),
+		Silent: true,
+	}
+}
+
+// FilterResults removes any results not matching the Where option, if set,
+// returning false if there are none left.  A single Result is set to nil if
+// it does not match, thus nothing will be printed.
+func (c *Cmd) FilterResults() (bool, error) {
+
+	if c.Options.Where == "" {
+		return true, nil
+	}
+	pred, err := Where(c.Options.Where)
+	if err != nil {
+		return false, CmdError{Code: CMD_OPTIONS_ERROR, Err: err}
+	}
+	if c.Results != nil {
+		matching := []*ParseResult{}
+		for _, res := range c.Results {
+			if res.Meta != nil && pred(res) {
+				matching = append(matching, res)
+			}
+		}
+		c.Results = matching
+		return len(matching) > 0, nil
+	}
+	if c.Result != nil && (c.Result.Meta == nil || !pred(c.Result)) {
+		c.Result = nil
+	}
+	return c.Result != nil, nil
 }
 
 // Fail fails with a useful message based on err; if err is a CmdError
@@ -173,10 +379,18 @@ func (c *Cmd) ParseFile() error {
 
 	var input []byte
 	var err error
+	var file *FileInfo
 	if c.Options.File == "" {
 		input, err = ioutil.ReadAll(c.Stdin)
 	} else {
 		input, err = ioutil.ReadFile(c.Options.File)
+		if err == nil {
+			var info os.FileInfo
+			info, err = os.Stat(c.Options.File)
+			if err == nil {
+				file = NewFileInfo(c.Options.File, info)
+			}
+		}
 	}
 	if err != nil {
 		return CmdError{
@@ -195,8 +409,17 @@ func (c *Cmd) ParseFile() error {
 	}
 
 	// NOTE: we should get back a partial result even when we have an error.
-	res, err := MarkdownCommon(input)
-	c.Result = res // cf. the Force option
+	parser := c.newParser()
+	if c.Options.Multi {
+		parser.Splitter = cmdSplitter(c.Options.Separator)
+		c.Results, err = parser.ParseMulti(input)
+	} else {
+		c.Result, err = parser.Parse(input) // cf. the Force option
+	}
+
+	// Any document or template rendering is done even with a parse error,
+	// though the parse error takes precedence.
+	renderErr := c.renderResults(parser, file)
 	if err != nil {
 		return CmdError{
 			Code:   CMD_PARSE_ERROR,
@@ -207,40 +430,290 @@ func (c *Cmd) ParseFile() error {
 		}
 	}
 
+	return renderErr
+
+}
+
+// newParser returns a new Parser with the Style, Cache and parser options
+// set.
+func (c *Cmd) newParser() *Parser {
+
+	parser := New()
+	parser.Style = c.Options.Style
+	parser.ExtractTables = c.Options.Tables
+	parser.SectionLevel = c.Options.SectionLevel
+	parser.WikiLinks = c.Options.WikiLinks
+	if c.Options.MetaAtEnd {
+		parser.MetaAtEnd = true
+	}
+	if c.Options.Cache != "" {
+		parser.Cache = NewCache(c.Options.Cache)
+	}
+	return parser
+}
+
+// renderResults sets the File of each of the command's results, and if so
+// configured in the Options, replaces its Content with a full document or
+// the output of a template.
+func (c *Cmd) renderResults(parser *Parser, file *FileInfo) error {
+
+	results := c.Results
+	if results == nil {
+		results = []*ParseResult{c.Result}
+	}
+	for _, res := range results {
+		res.File = file
+	}
+
+	var err error
+	if c.Options.Template != "" {
+		var tmpl *template.Template
+		tmpl, err = template.ParseGlob(c.Options.Template)
+		if err == nil {
+			for _, res := range results {
+				var content []byte
+				content, err = RenderTemplate(tmpl, res)
+				if err != nil {
+					break
+				}
+				res.Content = content
+			}
+		}
+	} else if c.Options.Document {
+		for _, res := range results {
+			if err = parser.wrapDocument(res); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return CmdError{
+			Code:   CMD_TEMPLATE_ERROR,
+			Err:    err,
+			File:   c.Options.File,
+			Silent: c.Options.Silent,
+			Force:  c.Options.Force,
+		}
+	}
+
 	return nil
+}
+
+// ParseFiles parses all the files in the Options' Files, as with ParseFile,
+// collecting their results in Results.  If the Recursive option is set, the
+// Files are walked as directories and every Markdown file found in them,
+// per the CollectionExtensions, is parsed.
+//
+// The first error encountered is returned, unless the Force option is set;
+// in that case errors are printed to Stderr (unless Silent), collected in
+// Errors, and the results of the broken files are left out.
+func (c *Cmd) ParseFiles() error {
 
+	files := c.Options.Files
+	if c.Options.Recursive {
+		var err error
+		if files, err = walkFiles(files); err != nil {
+			return CmdError{Code: CMD_FILE_ERROR, Err: err}
+		}
+	}
+
+	c.Results = []*ParseResult{}
+	for _, file := range files {
+		opts := *c.Options
+		opts.File, opts.Files = file, nil
+		sub := *c
+		sub.Options, sub.Result, sub.Results = &opts, nil, nil
+		if err := sub.ParseFile(); err != nil {
+			if !c.Options.Force {
+				return err
+			}
+			if !c.Options.Silent {
+				fmt.Fprintln(c.Stderr, err.Error())
+			}
+			c.Errors = append(c.Errors, err)
+			continue
+		}
+		if sub.Results != nil {
+			c.Results = append(c.Results, sub.Results...)
+		} else {
+			c.Results = append(c.Results, sub.Result)
+		}
+	}
+	return nil
+}
+
+// walkFiles returns the Markdown files found in the given directories (or
+// files), in lexical order.
+func walkFiles(roots []string) ([]string, error) {
+
+	files := []string{}
+	for _, root := range roots {
+		err := filepath.WalkDir(root,
+			func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && hasExtension(name, CollectionExtensions) {
+					files = append(files, name)
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// summarizeErrors prints a summary of any errors skipped with the Force
+// option to Stderr (unless Silent) and returns a CmdError with the exit
+// code of the first of them.  If there were no errors, nil is returned.
+func (c *Cmd) summarizeErrors() error {
+
+	if len(c.Errors) == 0 {
+		return nil
+	}
+	code := CMD_OTHER_ERROR
+	if e, ok := c.Errors[0].(CmdError); ok && e.Code != 0 {
+		code = e.Code
+	}
+	return CmdError{
+		Code: code,
+		Err: fmt.Errorf("%d of %d files failed.", len(c.Errors),
+			len(c.Results)+len(c.Errors)),
+		Silent: c.Options.Silent,
+	}
+}
+
+// cmdSplitter returns the Splitter for a --separator option: either a
+// heading marker such as "##" or a separator line, defaulting to the
+// DefaultMultiSeparator.
+func cmdSplitter(sep string) Splitter {
+
+	if sep == "" {
+		return SplitOnLine(DefaultMultiSeparator)
+	}
+	if strings.Trim(sep, "#") == "" {
+		return SplitOnHeading(len(sep))
+	}
+	return SplitOnLine(sep)
 }
 
 // PrintResult prints the Result according to the Options, with output
 // going to c.Stdout.  Any error returned should be considered fatal.
 // If Result is nil, nothing is printed; this is normal if the Test option
-// is set.
+// is set.  If Results is set, the results are printed as a list instead.
 func (c *Cmd) PrintResult() error {
 
-	res := c.Result
-	if res == nil || c.Options.Test {
+	if (c.Result == nil && c.Results == nil) || c.Options.Test {
 		return nil
 	}
+	results := c.Results
+	if results == nil {
+		results = []*ParseResult{c.Result}
+	}
 
 	// If we only want the content, life is very simple.
 	if c.Options.ContentOnly || c.Options.PlainMarkdown {
-		fmt.Fprintln(c.Stdout, string(res.Content))
+		for _, res := range results {
+			fmt.Fprintln(c.Stdout, string(res.Content))
+		}
+		return nil
+	}
+
+	// Several files may be printed as streams rather than lists.
+	yamlStream := c.Options.Files != nil && c.Options.Format == "yaml"
+	if c.Options.NDJSON || yamlStream {
+		for _, res := range results {
+			if yamlStream {
+				fmt.Fprintln(c.Stdout, "---")
+			}
+			if err := c.printSource(c.resultSource(res)); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
-	// With or without Content, the nature of the Meta means the encoder will
-	// need to use introspection (reflect).
 	var src interface{}
-	if c.Options.Format == "yaml" {
+	if c.Results == nil {
+		src = c.resultSource(c.Result)
+	} else {
+		src = c.listSource(c.Results)
+	}
 
-		if c.Options.MetaOnly {
-			src = res.Meta
-		} else {
-			src = map[string]interface{}{
-				"meta":    res.Meta,
-				"content": string(res.Content),
+	return c.printSource(src)
+}
+
+// cmdFileResult is a ParseResult with the path of its file, for output when
+// parsing several files.
+type cmdFileResult struct {
+	Path string `json:"path"`
+	*ParseResult
+}
+
+// resultSource returns the data structure to be serialized for res.  With
+// or without Content, the nature of the Meta means the encoder will need to
+// use introspection (reflect).  When parsing several files, or writing
+// NDJSON, the path of the file is included.
+func (c *Cmd) resultSource(res *ParseResult) interface{} {
+
+	src := c.documentSource(res)
+	if (c.Options.Files == nil && !c.Options.NDJSON) || res.File == nil {
+		return src
+	}
+	if c.Options.MetaOnly || c.Options.Select != nil {
+		return map[string]interface{}{"path": res.File.Path, "meta": src}
+	}
+	if m, ok := src.(map[string]interface{}); ok {
+		m["path"] = res.File.Path
+		return m
+	}
+	return cmdFileResult{Path: res.File.Path, ParseResult: res}
+}
+
+// documentSource returns the data structure to be serialized for res by
+// itself.
+func (c *Cmd) documentSource(res *ParseResult) interface{} {
+
+	if c.Options.Select != nil {
+		selected := map[string]interface{}{}
+		for _, key := range c.Options.Select {
+			if v := metaValue(res.Meta, key); v != nil {
+				selected[key] = v
 			}
 		}
+		return selected
+	}
+	if c.Options.MetaOnly {
+		return res.Meta
+	}
+	if c.Options.Format == "yaml" || c.Options.NoBase64 {
+		// Only []byte values are Base64-encoded, strings are not.  The
+		// other fields are omitted if empty, as for the ParseResult.
+		m := map[string]interface{}{
+			"meta":    res.Meta,
+			"content": string(res.Content),
+		}
+		if len(res.Tables) > 0 {
+			m["tables"] = res.Tables
+		}
+		if len(res.Sections) > 0 {
+			m["sections"] = res.Sections
+		}
+		if len(res.Warnings) > 0 {
+			m["warnings"] = res.Warnings
+		}
+		return m
+	}
+	return res
+}
+
+// printSource serializes src in the format set in the Options, and prints
+// it to c.Stdout.
+func (c *Cmd) printSource(src interface{}) error {
+
+	if c.Options.Format == "yaml" {
 		yaml, err := safeMarshalYaml(src)
 		if err != nil {
 			return CmdError{
@@ -254,20 +727,9 @@ func (c *Cmd) PrintResult() error {
 	}
 
 	// JSON, the default,  has additional options.
-	if c.Options.MetaOnly {
-		src = res.Meta
-	} else if c.Options.NoBase64 {
-		// Only []byte values are Base64-encoded, strings are not.
-		src = map[string]interface{}{
-			"meta":    res.Meta,
-			"content": string(res.Content),
-		}
-	} else {
-		src = res
-	}
 	var jsonBytes []byte
 	var err error
-	if c.Options.Indent {
+	if c.Options.Indent && !c.Options.NDJSON {
 		jsonBytes, err = json.MarshalIndent(src, "", "  ")
 	} else {
 		jsonBytes, err = json.Marshal(src)
@@ -339,6 +801,13 @@ func (c *Cmd) SetOptions() error {
 		"--content",
 		"--meta",
 		"--plainmd",
+		"--multi",
+		"--document",
+		"--reverse",
+		"--recursive",
+		"--ndjson",
+		"--pipe",
+		"--meta-at-end",
 		"--license",
 	}
 	have := map[string]bool{}
@@ -358,7 +827,57 @@ func (c *Cmd) SetOptions() error {
 	// favor of some other strategy.  However as of now it's not clear one
 	// even *could* set this to any type other than string in docopt, so we
 	// will not leave a hole in the test coverage for that.
-	file, _ := args["FILE"].(string)
+	var file string
+	var files []string
+	switch v := args["FILE"].(type) {
+	case string:
+		file = v
+	case []string:
+		if len(v) == 1 && !have["--recursive"] {
+			file = v[0]
+		} else if len(v) > 0 {
+			files = v
+		}
+	}
+	separator, _ := args["--separator"].(string)
+	style, _ := args["--style"].(string)
+	tmpl, _ := args["--template"].(string)
+	listen, _ := args["--listen"].(string)
+	cacheDir, _ := args["--cache"].(string)
+	tag, _ := args["--tag"].(string)
+	match, _ := args["--match"].(string)
+	since, _ := args["--since"].(string)
+	until, _ := args["--until"].(string)
+	sortKey, _ := args["--sort"].(string)
+	group, _ := args["--group"].(string)
+	where, _ := args["--where"].(string)
+	selectKeys := commaList(args["--select"])
+	rules := commaList(args["--rules"])
+	require := commaList(args["--require"])
+	addTo, _ := args["--add-to"].(string)
+
+	// Subcommands take a DIR or SRC and DST, which are otherwise up to the
+	// caller.
+	command, dir, out := "", "", ""
+	for _, name := range cmdCommands {
+		if v, _ := args[name].(bool); v {
+			command = name
+			dir, _ = args["DIR"].(string)
+			if src, ok := args["SRC"].(string); ok {
+				dir = src
+			}
+			out, _ = args["DST"].(string)
+			if paths, ok := args["PATH"].([]string); ok && len(paths) > 0 {
+				files = paths
+			}
+			if name == "set" || name == "unset" {
+				file, files = "", nil
+				if v, ok := args["FILE"].([]string); ok && len(v) > 0 {
+					file = v[0]
+				}
+			}
+		}
+	}
 
 	// Let's try to be upstanding OSS citizens here, just in principle.
 	if have["--license"] {
@@ -386,12 +905,34 @@ func (c *Cmd) SetOptions() error {
 			Code: CMD_OPTIONS_ERROR,
 		}
 	}
+	if have["--content"] && selectKeys != nil {
+		return CmdError{
+			Err:  errors.New("--select and --content are mutually exclusive."),
+			Code: CMD_OPTIONS_ERROR,
+		}
+	}
+	if where != "" {
+		if _, err := Where(where); err != nil {
+			return CmdError{
+				Err:  fmt.Errorf("Invalid --where expression: %s", err),
+				Code: CMD_OPTIONS_ERROR,
+			}
+		}
+	}
+	if have["--document"] && tmpl != "" {
+		return CmdError{
+			Err: errors.New(
+				"--document and --template are mutually exclusive."),
+			Code: CMD_OPTIONS_ERROR,
+		}
+	}
 	if have["--plainmd"] {
 		// PlainMarkdown overrides all other options at the moment.
 		// TODO: allow the "basic" option when we implement it.
 		format = ""
 		for k, v := range have {
-			if k != "--plainmd" && v == true {
+			if (k != "--plainmd" && v == true) ||
+				where != "" || selectKeys != nil {
 				return CmdError{
 					Err:  errors.New("--plainmd excludes other options."),
 					Code: CMD_OPTIONS_ERROR,
@@ -400,8 +941,87 @@ func (c *Cmd) SetOptions() error {
 		}
 	}
 
+	if outDir, _ := args["--out"].(string); outDir != "" {
+		out = outDir
+	}
+	if command == "watch" && out == "" {
+		return CmdError{
+			Err:  errors.New("--out is required for watch."),
+			Code: CMD_OPTIONS_ERROR,
+		}
+	}
+	var interval time.Duration
+	if v, _ := args["--interval"].(string); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return CmdError{
+				Err:  fmt.Errorf("Invalid --interval: %s", v),
+				Code: CMD_OPTIONS_ERROR,
+			}
+		}
+		interval = d
+	}
+
+	var maxSize int64
+	if v, _ := args["--max-size"].(string); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return CmdError{
+				Err:  fmt.Errorf("Invalid --max-size: %s", v),
+				Code: CMD_OPTIONS_ERROR,
+			}
+		}
+		maxSize = n
+	}
+
+	if have["--recursive"] && files == nil {
+		return CmdError{
+			Err:  errors.New("--recursive requires a directory."),
+			Code: CMD_OPTIONS_ERROR,
+		}
+	}
+	if err := CheckRules(rules); err != nil {
+		return CmdError{Err: err, Code: CMD_OPTIONS_ERROR}
+	}
+	if (command == "lint" || command == "check-links") &&
+		!have["--json"] && !have["--yaml"] {
+		format = "text"
+	}
+	var edits []MetaEdit
+	if command == "set" || command == "unset" {
+		assigns, _ := args["ASSIGN"].([]string)
+		if command == "unset" {
+			assigns, _ = args["KEY"].([]string)
+		}
+		var err error
+		if edits, err = metaEdits(command, assigns, addTo); err != nil {
+			return CmdError{Err: err, Code: CMD_OPTIONS_ERROR}
+		}
+	} else if addTo != "" {
+		return CmdError{
+			Err:  errors.New("--add-to is only for set."),
+			Code: CMD_OPTIONS_ERROR,
+		}
+	}
+	if have["--pipe"] && (file != "" || files != nil) {
+		return CmdError{
+			Err:  errors.New("--pipe reads from STDIN and takes no FILE."),
+			Code: CMD_OPTIONS_ERROR,
+		}
+	}
+	if (have["--ndjson"] || have["--pipe"]) && format == "yaml" {
+		return CmdError{
+			Err:  errors.New("Only one format allowed."),
+			Code: CMD_OPTIONS_ERROR,
+		}
+	}
+
 	c.Options = &CmdOptions{
 		File:          file,
+		Files:         files,
+		Recursive:     have["--recursive"],
+		NDJSON:        have["--ndjson"],
+		Pipe:          have["--pipe"],
 		Format:        format,
 		Force:         have["--force"],
 		Silent:        have["--silent"],
@@ -411,6 +1031,31 @@ func (c *Cmd) SetOptions() error {
 		ContentOnly:   have["--content"],
 		MetaOnly:      have["--meta"],
 		PlainMarkdown: have["--plainmd"],
+		Multi:         have["--multi"],
+		Separator:     separator,
+		Document:      have["--document"],
+		Style:         style,
+		Template:      tmpl,
+		Cache:         cacheDir,
+		Command:       command,
+		Dir:           dir,
+		Out:           out,
+		Interval:      interval,
+		Listen:        listen,
+		MaxSize:       maxSize,
+		Tag:           tag,
+		Match:         match,
+		Since:         since,
+		Until:         until,
+		Sort:          sortKey,
+		Reverse:       have["--reverse"],
+		Group:         group,
+		Where:         where,
+		Select:        selectKeys,
+		Rules:         rules,
+		Require:       require,
+		Edits:         edits,
+		MetaAtEnd:     have["--meta-at-end"],
 	}
 
 	return nil
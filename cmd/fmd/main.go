@@ -47,11 +47,8 @@
 //
 //  * Fix the goofy-ass escaping of non-base64 content in JSON.  What's up?
 //
-//  * -d option to produce a full HTML5 document in the Content.
-//
-//  * --style=X option to use an optional canned stylesheet *or* file
-//    (included in full) *or* link to stylesheet (if neither file nor
-//    canned style option).  Possibly with different options; TBD.
+//  * --style=X option to include a stylesheet file in full, in addition to
+//    the canned styles and linked stylesheets now supported.
 //
 //  *** TAG VERSION: 0.9 -- READY FOR USE, PENDING BUGFIXES ***
 //
@@ -83,13 +80,6 @@
 //    It would be nice to be able to tell FOR SURE what the parsed interface{}
 //    is, so e.g. timestamps can be debugged. Any other use-case?
 //    Any other formats? Asciidoc? (Probably a lot of work.)
-//
-//  * MAYBE --template=X option to use html/template and a file... MAYBE.
-//    Is this really useful for anything?  Wouldn't you need a bunch of
-//    template files? The tool is already at >3M, which is a little
-//    crazy, and adding template support would be at least another 1.1M.
-//    However, templates are a nice way to implement the -d option so
-//    it may be a moot point by the time we get here.
 package main
 
 import (
@@ -117,6 +107,58 @@ Converts Frosted Markdown files into structured data containing two top-level
 properties: 'meta' and 'content' -- the latter being the parsed HTML.  If no
 file is specified, the Markdown document is read from standard input.
 
+Several files may be given, or with the -r option whole directories; the
+output is then a list of results, each with the 'path' of its file.  With
+--ndjson each result is printed on its own line instead, and in YAML output
+as a separate document.
+
+With --pipe, fmd keeps reading requests from standard input, one JSON object
+per line, and answers each with a line of JSON on standard output:
+
+  {"id": 1, "path": "docs/intro.md"}
+  {"id": 2, "source": "# Hello", "options": {"nobase64": true}}
+
+Errors are reported in the answers, so one broken file does not stop the
+rest.  This saves starting a new process for every file.
+
+The build command renders a whole tree of Markdown files in SRC to HTML
+pages in DST, rewriting links to .md files, copying any other files as-is,
+and adding an index page to directories without an index.md.  Pages are
+full HTML5 documents using --style, or use the --template layout if given.
+
+The watch command builds DIR into the --out directory the same way, then
+keeps watching it: changed files, and files including them, are rebuilt as
+soon as the changes settle.  Errors are reported with their file and line,
+and do not stop the watch.
+
+The serve command starts a local web server for previewing DIR: pages are
+rendered on every request exactly as build would render them, errors are
+shown on the page itself, and the browser reloads whenever a file changes.
+
+The server command serves the conversion itself as a JSON API: POST raw
+Markdown to /parse, with options in the query (/parse?meta&indent), or a
+JSON object {"source": ..., "options": {...}}, and get back what fmd would
+print for it.  GET /health reports the status and version.
+
+The lint command checks the Markdown files in each PATH for common problems,
+printing each as "file:line: rule: message", or as a list with -j.  All the
+rules listed under Lint options are checked unless --rules is given.
+
+The check-links command checks every relative link and image in the
+Markdown files under DIR: the target must exist, and a #fragment must match
+a heading ID in the target, as in "# Setup {#setup}".  External links are
+listed but never fetched, so it works offline.
+
+The set and unset commands edit the meta block of FILE in place: set takes
+assignments like Title="A Title" or Tags=[a,b], and --add-to Tags=c adds to
+a list; unset removes the KEYs.  Only the edited keys change: the block
+keeps its language, indentation and position, and the rest of the file is
+untouched.  A YAML block is added if there is none.
+
+With --cache, parse results are kept in the given directory and files that
+have not changed, nor have any of their includes, are not parsed again.  This
+makes repeated builds of large trees much faster.
+
 Note that in JSON output the HTML content is base64-encoded; this actually
 saves significant space in the JSON file for any nontrivial amount of content.
 
@@ -140,6 +182,11 @@ https://github.com/biztos/frostedmd
   2: Filesystem error.
   3: Document-parsing error.
   4: Serialization error (should never happen).
+  5: Template error.
+  6: No documents matched the --where expression.
+  7: Server error.
+  8: Lint problems found.
+  9: Broken links found.
 
 Examples:
 
@@ -193,6 +240,12 @@ Examples:
     fmd
     golang
     nerdery
+
+  Simple filtering is also built in, and the exit code tells you whether
+  anything matched:
+
+    $ fmd --where 'Tags contains "golang"' --select Title sample.md
+    {"Title":"FMD FTW"}
   
 Acknowledgements:
   This software would have been immensely harder to write without the
@@ -0,0 +1,83 @@
+// cmd_build.go - the "build" subcommand.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"fmt"
+	"html/template"
+	"os"
+)
+
+// Build builds the static site in the Options' Dir into the Out directory
+// with a Builder, resolving includes within Dir.  The Template option, if
+// set, is used as the Layout, and the Style option is used for document
+// pages otherwise.
+//
+// Documents that fail to build are reported to Stderr (unless Silent) and
+// the command fails at the end, unless the Force option is set.  A summary
+// is printed to Stdout unless the Test option is set.
+func (c *Cmd) Build() error {
+
+	builder, err := c.newBuilder()
+	if err != nil {
+		return err
+	}
+	src := os.DirFS(c.Options.Dir)
+	builder.Parser.FS = src
+	report, err := builder.Build(src, c.Options.Out)
+	if err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err}
+	}
+	c.printReport("Built", report)
+
+	if len(report.Errors) > 0 && !c.Options.Force {
+		return CmdError{
+			Code:   CMD_PARSE_ERROR,
+			Err:    fmt.Errorf("%d documents failed.", len(report.Errors)),
+			Silent: c.Options.Silent,
+		}
+	}
+	return nil
+}
+
+// newBuilder returns a Builder for the options, with the Template option
+// parsed as its Layout.
+func (c *Cmd) newBuilder() (*Builder, error) {
+
+	builder := &Builder{Parser: c.newParser()}
+	if c.Options.Template != "" {
+		tmpl, err := template.ParseGlob(c.Options.Template)
+		if err != nil {
+			return nil, CmdError{Code: CMD_TEMPLATE_ERROR, Err: err}
+		}
+		builder.Layout = tmpl
+	}
+	return builder, nil
+}
+
+// printReport prints the errors in the report to Stderr unless Silent, with
+// their positions where known, and a summary starting with verb to Stdout
+// unless Test.
+func (c *Cmd) printReport(verb string, report *BuildReport) {
+
+	if !c.Options.Silent {
+		for _, err := range report.Errors {
+			if be, ok := err.(BuildError); ok {
+				fmt.Fprintf(c.Stderr, "%s: %s\n", be.Position(), be.Err)
+			} else {
+				fmt.Fprintln(c.Stderr, err.Error())
+			}
+		}
+	}
+	if c.Options.Test {
+		return
+	}
+	fmt.Fprintf(c.Stdout, "%s %d pages, %d indexes and %d assets in %s",
+		verb, len(report.Pages), len(report.Indexes), len(report.Assets),
+		c.Options.Out)
+	if len(report.Removed) > 0 {
+		fmt.Fprintf(c.Stdout, "; removed %d", len(report.Removed))
+	}
+	fmt.Fprintln(c.Stdout, ".")
+}
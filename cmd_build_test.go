@@ -0,0 +1,105 @@
+// cmd_build_test.go
+
+package frostedmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_Build(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"testing",
+		"build",
+		"--style=dark",
+		"--force",
+		"srcdir",
+		"dstdir",
+	}
+	exp := &frostedmd.CmdOptions{
+		Format:  "json",
+		Command: "build",
+		Dir:     "srcdir",
+		Out:     "dstdir",
+		Style:   "dark",
+		Force:   true,
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+}
+
+func Test_Build(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dst := t.TempDir()
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command: "build",
+		Dir:     filepath.Join("test", "collection"),
+		Out:     dst,
+		Style:   "none",
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Build()
+	if assert.Nil(err, "no error") {
+		assert.Equal("Built 3 pages, 2 indexes and 1 assets in "+dst+".\n",
+			rec.StdoutString(), "summary printed")
+		assert.Equal("", rec.StderrString(), "nothing on stderr")
+		for _, name := range []string{"alpha.html", "index.html",
+			"notes.txt", "sub/gamma.html", "sub/index.html"} {
+			_, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name)))
+			assert.Nil(err, "%s built", name)
+		}
+	}
+
+}
+
+func Test_Build_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		opts frostedmd.CmdOptions
+		code int
+	}{
+		{frostedmd.CmdOptions{Dir: "test/collection", Template: "nope/[x"},
+			frostedmd.CMD_TEMPLATE_ERROR},
+		{frostedmd.CmdOptions{Dir: "no/such/dir"},
+			frostedmd.CMD_FILE_ERROR},
+		{frostedmd.CmdOptions{Dir: "test"},
+			frostedmd.CMD_PARSE_ERROR},
+	} {
+		opts := tc.opts
+		opts.Command = "build"
+		opts.Out = t.TempDir()
+		opts.Test = true
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &opts
+		rec := testig.NewOutputRecorder()
+		cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+		err := cmd.Build()
+		if assert.Error(err, "error for %+v", tc.opts) {
+			if cmdErr, ok := err.(frostedmd.CmdError); assert.True(ok,
+				"CmdError for %+v", tc.opts) {
+				assert.Equal(tc.code, cmdErr.Code, "code for %+v", tc.opts)
+			}
+		}
+	}
+
+}
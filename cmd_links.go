@@ -0,0 +1,61 @@
+// cmd_links.go - the "check-links" subcommand.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CheckLinks checks the links in the Markdown files under the Options' Dir
+// per the Parser's CheckLinks.  Broken links are printed to Stdout one per
+// line, as "file:line: target: reason", followed by the external links,
+// which are not checked; or the whole LinkReport is printed if the Format
+// is JSON or YAML.  With the Test option nothing is printed.
+//
+// If any links are broken, a CmdError with the CMD_LINK_ERROR code is
+// returned, summarizing them.
+func (c *Cmd) CheckLinks() error {
+
+	if _, err := os.Stat(c.Options.Dir); err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err}
+	}
+	report, err := c.newParser().CheckLinks(os.DirFS(c.Options.Dir), ".")
+	if err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err}
+	}
+	for i := range report.Broken {
+		report.Broken[i].Path = filepath.Join(c.Options.Dir,
+			filepath.FromSlash(report.Broken[i].Path))
+	}
+	for i := range report.External {
+		report.External[i].Path = filepath.Join(c.Options.Dir,
+			filepath.FromSlash(report.External[i].Path))
+	}
+
+	if !c.Options.Test {
+		if c.Options.Format != "text" {
+			if err := c.printSource(report); err != nil {
+				return err
+			}
+		} else {
+			for _, link := range report.Broken {
+				fmt.Fprintln(c.Stdout, link.String())
+			}
+			for _, link := range report.External {
+				fmt.Fprintln(c.Stdout, link.String()+": External, not checked.")
+			}
+		}
+	}
+	if len(report.Broken) == 0 {
+		return nil
+	}
+	return CmdError{
+		Code: CMD_LINK_ERROR,
+		Err: fmt.Errorf("%d of %d links broken in %d files.",
+			len(report.Broken), report.Links, report.Files),
+		Silent: c.Options.Silent,
+	}
+}
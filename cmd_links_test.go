@@ -0,0 +1,101 @@
+// cmd_links_test.go
+
+package frostedmd_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_CheckLinks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{"testing", "check-links", "docs"}
+	exp := &frostedmd.CmdOptions{
+		Format:  "text",
+		Command: "check-links",
+		Dir:     "docs",
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+}
+
+func Test_CheckLinks_Cmd(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.md": "# A\n\n[B](b.md#top) and [C](c.md).\n\n" +
+			"<https://example.com>\n",
+		"b.md": "# B {#top}\n\nBack to [A](a.md).\n",
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := filepath.Join(dir, "a.md")
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command: "check-links",
+		Format:  "text",
+		Dir:     dir,
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.CheckLinks()
+	if assert.Error(err, "error for broken link") {
+		if e, ok := err.(frostedmd.CmdError); assert.True(ok, "CmdError") {
+			assert.Equal(frostedmd.CMD_LINK_ERROR, e.Code, "link code")
+			assert.Equal("1 of 3 links broken in 2 files.", e.Error(),
+				"summary")
+		}
+	}
+	assert.Equal(a+":3: c.md: File not found.\n"+
+		a+":5: https://example.com: External, not checked.\n",
+		rec.StdoutString(), "links printed")
+
+	// JSON has the whole report.
+	cmd.Options.Format = "json"
+	rec = testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+	cmd.CheckLinks()
+	report := &frostedmd.LinkReport{}
+	if assert.Nil(json.Unmarshal(rec.Stdout.Bytes(), report), "valid JSON") {
+		assert.Equal(2, report.Files, "files in report")
+		assert.Equal(1, len(report.Broken), "broken in report")
+		assert.Equal(1, len(report.External), "external in report")
+	}
+
+	// Removing a file breaks the links to it.
+	os.Remove(a)
+	rec = testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+	cmd.Options.Format = "text"
+	err = cmd.CheckLinks()
+	if assert.Error(err, "b.md now broken") {
+		assert.Equal(filepath.Join(dir, "b.md")+":3: a.md: File not found.\n",
+			rec.StdoutString(), "newly broken link")
+	}
+
+	cmd.Options.Dir = filepath.Join(dir, "nope")
+	err = cmd.CheckLinks()
+	if e, ok := err.(frostedmd.CmdError); assert.True(ok, "CmdError") {
+		assert.Equal(frostedmd.CMD_FILE_ERROR, e.Code, "file code")
+	}
+
+}
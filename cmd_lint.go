@@ -0,0 +1,98 @@
+// cmd_lint.go - the "lint" subcommand.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Lint checks the Markdown files in the Options' Files, which may be files
+// or directories, with a Linter for the Rules and Require options.  The
+// findings are printed to Stdout one per line, as "file:line: rule:
+// message", or as a JSON or YAML list if that Format is set; with the Test
+// option nothing is printed.
+//
+// If there are any findings, a CmdError with the CMD_LINT_ERROR code is
+// returned, summarizing them.
+func (c *Cmd) Lint() error {
+
+	linter := NewLinter()
+	linter.Parser = c.newParser()
+	linter.Rules = c.Options.Rules
+	linter.Required = c.Options.Require
+
+	findings := []LintFinding{}
+	files := 0
+	for _, root := range c.Options.Files {
+		info, err := os.Stat(root)
+		if err != nil {
+			return CmdError{Code: CMD_FILE_ERROR, Err: err}
+		}
+		// Files are parsed in the FS of their directory, or of the
+		// directory given, so that paths are as for query and build.
+		dir, paths := filepath.Dir(root), []string{root}
+		if info.IsDir() {
+			if paths, err = walkFiles([]string{root}); err != nil {
+				return CmdError{Code: CMD_FILE_ERROR, Err: err}
+			}
+			dir = root
+		}
+		fsys := os.DirFS(dir)
+		for _, path := range paths {
+			name, err := filepath.Rel(dir, path)
+			if err != nil {
+				return CmdError{Code: CMD_FILE_ERROR, Err: err, File: path}
+			}
+			found, err := linter.LintFile(fsys, filepath.ToSlash(name), path)
+			if err != nil {
+				return CmdError{Code: CMD_FILE_ERROR, Err: err, File: path}
+			}
+			findings = append(findings, found...)
+			files++
+		}
+	}
+	findings = append(findings, linter.Duplicates()...)
+	SortFindings(findings)
+
+	if !c.Options.Test {
+		if err := c.printFindings(findings); err != nil {
+			return err
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	failed := map[string]bool{}
+	for _, f := range findings {
+		failed[f.Path] = true
+	}
+	return CmdError{
+		Code: CMD_LINT_ERROR,
+		Err: fmt.Errorf("%d problems in %d of %d files.", len(findings),
+			len(failed), files),
+		Silent: c.Options.Silent,
+	}
+}
+
+// printFindings prints the findings in the Format of the Options.
+func (c *Cmd) printFindings(findings []LintFinding) error {
+
+	if c.Options.Format != "text" {
+		if !c.Options.NDJSON {
+			return c.printSource(findings)
+		}
+		for _, f := range findings {
+			if err := c.printSource(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, f := range findings {
+		fmt.Fprintln(c.Stdout, f.String())
+	}
+	return nil
+}
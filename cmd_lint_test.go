@@ -0,0 +1,151 @@
+// cmd_lint_test.go
+
+package frostedmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_Lint(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{"testing", "lint", "--rules=empty, image-alt",
+		"--require=Date,Tags", "docs", "x.md"}
+	exp := &frostedmd.CmdOptions{
+		Format:  "text",
+		Command: "lint",
+		Files:   []string{"docs", "x.md"},
+		Rules:   []string{"empty", "image-alt"},
+		Require: []string{"Date", "Tags"},
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+
+	os.Args = []string{"testing", "lint", "-j", "docs"}
+	err = cmd.SetOptions()
+	if assert.Nil(err, "no error with -j") {
+		assert.Equal("json", cmd.Options.Format, "JSON format")
+	}
+
+	os.Args = []string{"testing", "lint", "--rules=nope", "docs"}
+	err = cmd.SetOptions()
+	if assert.Error(err, "error for unknown rule") {
+		assert.Equal("Unknown lint rule: nope", err.Error(), "message")
+	}
+}
+
+// writeLintFiles writes a small set of files to lint into a new directory.
+func writeLintFiles(t *testing.T) string {
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.md":     "# A\n\n    Date: 2024-01-01\n\nText.\n",
+		"b.md":     "# A\n\n    Date: 2024-01-01\n\n![](x.png)\n",
+		"c/c.md":   "# C\n\nNo date.\n",
+		"note.txt": "not Markdown\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_Lint(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir := writeLintFiles(t)
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	c := filepath.Join(dir, "c", "c.md")
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command: "lint",
+		Format:  "text",
+		Files:   []string{dir},
+		Require: []string{"Date"},
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Lint()
+	if assert.Error(err, "error for findings") {
+		if e, ok := err.(frostedmd.CmdError); assert.True(ok, "CmdError") {
+			assert.Equal(frostedmd.CMD_LINT_ERROR, e.Code, "lint code")
+			assert.Equal("3 problems in 2 of 3 files.", e.Error(),
+				"summary")
+		}
+	}
+	assert.Equal(b+":1: duplicate-title: Title \"A\" is also used by "+a+
+		".\n"+b+":5: image-alt: Image has no alt text.\n"+
+		c+":1: required-key: Missing required key \"Date\".\n",
+		rec.StdoutString(), "findings printed")
+
+	// A single file, in JSON.
+	cmd.Options.Files = []string{c}
+	cmd.Options.Format = "json"
+	rec = testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+	err = cmd.Lint()
+	assert.Error(err, "error for findings")
+	assert.JSONEq(`[{"path":"`+c+`","line":1,"rule":"required-key",`+
+		`"message":"Missing required key \"Date\"."}]`,
+		rec.StdoutString(), "JSON findings")
+
+	// No findings, no error.
+	cmd.Options.Files = []string{a}
+	cmd.Options.Format = "text"
+	rec = testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+	assert.Nil(cmd.Lint(), "no error for a good file")
+	assert.Equal("", rec.StdoutString(), "nothing printed")
+
+	// Missing files are errors.
+	cmd.Options.Files = []string{filepath.Join(dir, "nope")}
+	err = cmd.Lint()
+	if e, ok := err.(frostedmd.CmdError); assert.True(ok, "CmdError") {
+		assert.Equal(frostedmd.CMD_FILE_ERROR, e.Code, "file code")
+	}
+
+}
+
+func Test_Run_Lint(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir := writeLintFiles(t)
+	exit := -1
+	os.Args = []string{"testing", "lint", "--rules=image-alt", "-s", dir}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Exit = func(code int) { exit = code }
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Run()
+	if assert.Error(err, "error for findings") {
+		cmd.Fail(err)
+	}
+	assert.Equal(frostedmd.CMD_LINT_ERROR, exit, "exit code")
+	assert.Equal(filepath.Join(dir, "b.md")+
+		":5: image-alt: Image has no alt text.\n", rec.StdoutString(),
+		"one finding")
+	assert.Equal("", rec.StderrString(), "silent")
+
+}
@@ -0,0 +1,85 @@
+// cmd_meta.go - the "set" and "unset" subcommands.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EditMeta makes the Options' Edits to the Meta Block of the Options' File
+// per the Parser's EditMeta, and writes the file back in place, keeping its
+// permissions.  The file is not written if nothing changed, or if the Test
+// option is set.  Nothing is printed on success.
+func (c *Cmd) EditMeta() error {
+
+	file := c.Options.File
+	info, err := os.Stat(file)
+	if err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err}
+	}
+	input, err := os.ReadFile(file)
+	if err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err, File: file}
+	}
+	output, err := c.newParser().EditMeta(input, c.Options.Edits...)
+	if err != nil {
+		return CmdError{
+			Code:   CMD_PARSE_ERROR,
+			Err:    err,
+			File:   file,
+			Silent: c.Options.Silent,
+		}
+	}
+	if c.Options.Test || string(output) == string(input) {
+		return nil
+	}
+	if err := os.WriteFile(file, output, info.Mode().Perm()); err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err, File: file}
+	}
+	return nil
+}
+
+// metaEdits returns the edits for the set and unset subcommands: an
+// assignment "KEY=VALUE" for each of sets, followed by the --add-to option
+// if any, or a KEY for each of unsets.  Assigned values are read per
+// ParseMetaValue; the --add-to value is always a string.
+func metaEdits(command string, args []string, addTo string) ([]MetaEdit, error) {
+
+	edits := []MetaEdit{}
+	if command == "unset" {
+		for _, key := range args {
+			edits = append(edits, MetaEdit{Op: MetaUnset, Key: key})
+		}
+		return edits, nil
+	}
+	for _, arg := range args {
+		eq := strings.IndexByte(arg, '=')
+		if eq < 1 {
+			return nil, fmt.Errorf("Invalid assignment: %s", arg)
+		}
+		edits = append(edits, MetaEdit{
+			Op:    MetaSet,
+			Key:   arg[:eq],
+			Value: ParseMetaValue(arg[eq+1:]),
+		})
+	}
+	if addTo != "" {
+		eq := strings.IndexByte(addTo, '=')
+		if eq < 1 {
+			return nil, errors.New("--add-to must be of the form KEY=VAL.")
+		}
+		edits = append(edits, MetaEdit{
+			Op:    MetaAdd,
+			Key:   addTo[:eq],
+			Value: addTo[eq+1:],
+		})
+	}
+	if len(edits) == 0 {
+		return nil, errors.New("Nothing to set.")
+	}
+	return edits, nil
+}
@@ -0,0 +1,187 @@
+// cmd_meta_test.go
+
+package frostedmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_Set(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{"testing", "set", "doc.md", "Title=Hello, World",
+		"Weight=3", "Version=1.10", "Answer=no", "Nothing=~",
+		"--add-to", "Tags=y"}
+	exp := &frostedmd.CmdOptions{
+		File:    "doc.md",
+		Format:  "json",
+		Command: "set",
+		Edits: []frostedmd.MetaEdit{
+			{Op: frostedmd.MetaSet, Key: "Title", Value: "Hello, World"},
+			{Op: frostedmd.MetaSet, Key: "Weight", Value: 3},
+			{Op: frostedmd.MetaSet, Key: "Version", Value: "1.10"},
+			{Op: frostedmd.MetaSet, Key: "Answer", Value: "no"},
+			{Op: frostedmd.MetaSet, Key: "Nothing", Value: "~"},
+			{Op: frostedmd.MetaAdd, Key: "Tags", Value: "y"},
+		},
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+
+	os.Args = []string{"testing", "unset", "doc.md", "Draft", "Tags"}
+	err = cmd.SetOptions()
+	if assert.Nil(err, "no error for unset") {
+		assert.Equal("doc.md", cmd.Options.File, "file set")
+		assert.Equal([]frostedmd.MetaEdit{
+			{Op: frostedmd.MetaUnset, Key: "Draft"},
+			{Op: frostedmd.MetaUnset, Key: "Tags"},
+		}, cmd.Options.Edits, "unset edits")
+	}
+
+	for _, args := range [][]string{
+		{"testing", "set", "doc.md", "Title"},
+		{"testing", "set", "doc.md", "=x"},
+		{"testing", "set", "doc.md"},
+		{"testing", "set", "doc.md", "--add-to", "Tags"},
+		{"testing", "doc.md", "--add-to", "Tags=go"},
+	} {
+		os.Args = args
+		err := cmd.SetOptions()
+		if e, ok := err.(frostedmd.CmdError); assert.True(ok, "CmdError %v", args) {
+			assert.Equal(frostedmd.CMD_OPTIONS_ERROR, e.Code, "options code")
+		}
+	}
+}
+
+func Test_EditMeta_Cmd(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "doc.md")
+	input := "# Title\n\n```json\n{\n  \"Tags\": [\"a\"],\n  \"Draft\": true\n}\n" +
+		"```\n\nText.\n"
+	if err := os.WriteFile(file, []byte(input), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command: "set",
+		File:    file,
+		Edits: []frostedmd.MetaEdit{
+			{Op: frostedmd.MetaAdd, Key: "Tags", Value: "b"},
+			{Op: frostedmd.MetaUnset, Key: "Draft"},
+		},
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	// With the Test option the file is not written.
+	cmd.Options.Test = true
+	if assert.Nil(cmd.EditMeta(), "no error for test") {
+		b, _ := os.ReadFile(file)
+		assert.Equal(input, string(b), "file unchanged")
+	}
+
+	cmd.Options.Test = false
+	if assert.Nil(cmd.EditMeta(), "no error") {
+		b, _ := os.ReadFile(file)
+		assert.Equal("# Title\n\n```json\n{\n  \"Tags\": [\"a\", \"b\"]\n}\n"+
+			"```\n\nText.\n", string(b), "file edited")
+		info, _ := os.Stat(file)
+		assert.Equal(os.FileMode(0600), info.Mode().Perm(), "mode kept")
+	}
+	assert.Equal("", rec.StdoutString(), "nothing printed")
+
+	cmd.Options.Edits = []frostedmd.MetaEdit{
+		{Op: frostedmd.MetaAdd, Key: "Tags", Value: "c"},
+	}
+	os.WriteFile(file, []byte("    Tags: {a: 1}\n"), 0600)
+	err := cmd.EditMeta()
+	if e, ok := err.(frostedmd.CmdError); assert.True(ok, "CmdError") {
+		assert.Equal(frostedmd.CMD_PARSE_ERROR, e.Code, "parse code")
+		assert.Equal(file+": Cannot add to Tags: not a list.", e.Error(),
+			"error message")
+	}
+
+	cmd.Options.File = file + ".nope"
+	err = cmd.EditMeta()
+	if e, ok := err.(frostedmd.CmdError); assert.True(ok, "CmdError") {
+		assert.Equal(frostedmd.CMD_FILE_ERROR, e.Code, "file code")
+	}
+}
+
+func Test_EditMeta_Cmd_Strings(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(file, []byte("# T\n\n    Tags: [a]\n\nText.\n"),
+		0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Args = []string{"testing", "set", file, "Version=1.10", "Answer=no",
+		"Nothing=~", "Weight=3", "--add-to", "Tags=y"}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	if !assert.Nil(cmd.SetOptions(), "no options error") ||
+		!assert.Nil(cmd.EditMeta(), "no error") {
+		return
+	}
+	b, _ := os.ReadFile(file)
+	assert.Equal("# T\n\n    Tags: [a, \"y\"]\n    Version: \"1.10\"\n"+
+		"    Answer: \"no\"\n    Nothing: \"~\"\n    Weight: 3\n\nText.\n",
+		string(b), "strings quoted")
+	res, err := frostedmd.New().Parse(b)
+	if assert.Nil(err, "no parse error") {
+		assert.Equal(map[string]interface{}{
+			"Title":   "T",
+			"Tags":    []interface{}{"a", "y"},
+			"Version": "1.10",
+			"Answer":  "no",
+			"Nothing": "~",
+			"Weight":  3,
+		}, res.Meta, "values read back as given")
+	}
+}
+
+func Test_EditMeta_Cmd_MetaAtEnd(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(file, []byte("# T\n\nText.\n\n    Title: Old\n"),
+		0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+
+	os.Args = []string{"testing", "set", "--meta-at-end", file,
+		"Title=New", "Draft=true"}
+	if assert.Nil(cmd.SetOptions(), "no options error for set") &&
+		assert.Nil(cmd.EditMeta(), "no error for set") {
+		assert.True(cmd.Options.MetaAtEnd, "MetaAtEnd set")
+		b, _ := os.ReadFile(file)
+		assert.Equal("# T\n\nText.\n\n    Title: New\n    Draft: true\n",
+			string(b), "block at end edited")
+	}
+
+	os.Args = []string{"testing", "unset", "-e", file, "Draft"}
+	if assert.Nil(cmd.SetOptions(), "no options error for unset") &&
+		assert.Nil(cmd.EditMeta(), "no error for unset") {
+		b, _ := os.ReadFile(file)
+		assert.Equal("# T\n\nText.\n\n    Title: New\n", string(b),
+			"key unset from block at end")
+	}
+}
@@ -0,0 +1,156 @@
+// cmd_pipe.go - the --pipe mode, parsing requests from a stream.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PipeRequest is a request read by the Pipe method: the Markdown Source to
+// parse, or if it is empty the file at Path, with the Options to apply on
+// top of those of the command.  The ID can be any JSON value, and is
+// returned with the result.
+type PipeRequest struct {
+	ID      json.RawMessage     `json:"id"`
+	Path    string              `json:"path"`
+	Source  string              `json:"source"`
+	Options ParseRequestOptions `json:"options"`
+}
+
+// pipeResult is the output for a PipeRequest: the Result as the command
+// would print it, or the Error message, with the Line of a meta error if
+// known.
+type pipeResult struct {
+	ID     json.RawMessage `json:"id"`
+	Path   string          `json:"path,omitempty"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Line   int             `json:"line,omitempty"`
+}
+
+// Pipe reads newline-delimited JSON PipeRequests from Stdin until it ends,
+// and for each of them writes a line of JSON to Stdout with the "id" and
+// "path" of the request and either the "result" or the "error".  Blank
+// lines are skipped.  Thus a single fmd process can serve any number of
+// documents, for instance:
+//
+//	{"id": 1, "path": "docs/intro.md"}
+//	{"id": 2, "source": "# Hello\n\nWorld.", "options": {"meta": true}}
+//
+// The output options of the command, such as Meta and NoBase64, are the
+// defaults for the request options, which have the same names as in the
+// server API; see ParseHandler.  The Cache and Template options apply to
+// all requests.
+//
+// Errors in requests, including unreadable files, are reported in their
+// results and do not stop the Pipe; only an error reading Stdin or writing
+// to Stdout is returned.
+func (c *Cmd) Pipe() error {
+
+	defaults := ParseRequestOptions{
+		Meta:          c.Options.MetaOnly,
+		Content:       c.Options.ContentOnly,
+		NoBase64:      c.Options.NoBase64,
+		PlainMarkdown: c.Options.PlainMarkdown,
+		Document:      c.Options.Document,
+		Style:         c.Options.Style,
+		Multi:         c.Options.Multi,
+		Separator:     c.Options.Separator,
+		Select:        c.Options.Select,
+	}
+	r := bufio.NewReader(c.Stdin)
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			req := &PipeRequest{Options: defaults}
+			var res *pipeResult
+			dec := json.NewDecoder(bytes.NewReader(line))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(req); err != nil {
+				res = &pipeResult{
+					Error: fmt.Sprintf("Invalid request: %s", err),
+				}
+			} else {
+				res = c.pipeRequest(req)
+			}
+			out, err := json.Marshal(res)
+			if err != nil {
+				out, _ = json.Marshal(&pipeResult{ID: res.ID,
+					Path: res.Path, Error: err.Error()})
+			}
+			if _, err := c.Stdout.Write(append(out, '\n')); err != nil {
+				return CmdError{Code: CMD_OTHER_ERROR, Err: err}
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return CmdError{Code: CMD_FILE_ERROR, Err: readErr}
+		}
+	}
+}
+
+// pipeRequest returns the result of a single request.
+func (c *Cmd) pipeRequest(req *PipeRequest) *pipeResult {
+
+	res := &pipeResult{ID: req.ID, Path: req.Path}
+	opts, err := c.requestOptions(&req.Options)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	sub := &Cmd{
+		Name:    c.Name,
+		Version: c.Version,
+		Options: opts,
+		Exit:    c.Exit,
+		Stdin:   strings.NewReader(req.Source),
+		Stdout:  io.Discard,
+		Stderr:  io.Discard,
+	}
+	if req.Source == "" {
+		if req.Path == "" {
+			res.Error = "Request has neither source nor path."
+			return res
+		}
+		opts.File = req.Path
+	}
+	if err := sub.ParseFile(); err != nil {
+		res.Error = err.Error()
+		var me MetaError
+		if errors.As(err, &me) {
+			res.Line = me.Line
+		}
+		return res
+	}
+
+	if sub.Results != nil {
+		list := make([]interface{}, len(sub.Results))
+		for i, doc := range sub.Results {
+			list[i] = sub.pipeSource(doc)
+		}
+		res.Result = list
+	} else {
+		res.Result = sub.pipeSource(sub.Result)
+	}
+	return res
+}
+
+// pipeSource returns the data structure for doc in a pipe result: the
+// content as a string if only the content is wanted, otherwise as it would
+// be printed.
+func (c *Cmd) pipeSource(doc *ParseResult) interface{} {
+
+	if c.Options.ContentOnly || c.Options.PlainMarkdown {
+		return string(doc.Content)
+	}
+	return c.documentSource(jsonResult(doc))
+}
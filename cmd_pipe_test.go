@@ -0,0 +1,98 @@
+// cmd_pipe_test.go
+
+package frostedmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_Pipe(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{"testing", "--pipe", "-m"}
+	exp := &frostedmd.CmdOptions{
+		Format:   "json",
+		Pipe:     true,
+		MetaOnly: true,
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+
+	for _, args := range [][]string{
+		{"--pipe", "a.md"},
+		{"--pipe", "-y"},
+	} {
+		os.Args = append([]string{"testing"}, args...)
+		err := cmd.SetOptions()
+		if assert.Error(err, "error for %v", args) {
+			e, _ := err.(frostedmd.CmdError)
+			assert.Equal(frostedmd.CMD_OPTIONS_ERROR, e.Code,
+				"error code is 'options' for %v", args)
+		}
+	}
+}
+
+func Test_Run_Pipe(t *testing.T) {
+
+	assert := assert.New(t)
+
+	alpha := filepath.Join("test", "collection", "alpha.md")
+	input := `{"id": 1, "path": "` + alpha + `"}
+
+{"id": "two", "source": "# Two\n\nText.", "options": {"meta": false}}
+{"id": [3], "source": "# Three\n\n    Foo: [\n\nBar."}
+{"id": 4, "path": "no/such/file.md"}
+{"id": 5, "source": "x", "options": {"content": true, "meta": true}}
+{"id": 6}
+{"id": 7, "bogus": true}
+not json
+{"id": 9, "source": "# A\n\n+++\n\n# B", "options": {"multi": true}}`
+
+	os.Args = []string{"test", "--pipe", "-m"}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Stdin = strings.NewReader(input)
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Run()
+	assert.Nil(err, "no error on Run")
+	assert.Equal("", rec.StderrString(), "no stderr")
+
+	lines := strings.Split(strings.TrimSuffix(rec.StdoutString(), "\n"), "\n")
+	if !assert.Equal(9, len(lines), "one line per request") {
+		return
+	}
+	assert.JSONEq(`{"id":1,"path":"`+alpha+`","result":{"Date":"2024-02-01",`+
+		`"Tags":["golang","markdown"],"Title":"Alpha","Weight":2}}`,
+		lines[0], "meta of path")
+	assert.JSONEq(`{"id":"two","result":{"meta":{"Title":"Two"},`+
+		`"content":"PGgxPlR3bzwvaDE+Cgo8cD5UZXh0LjwvcD4K"}}`,
+		lines[1], "request options override the command's")
+	assert.Regexp(`^\{"id":\[3\],"error":"yaml: .*","line":3\}$`, lines[2],
+		"meta error with line")
+	assert.Regexp(`^\{"id":4,"path":"no/such/file.md","error":".*"\}$`,
+		lines[3], "file error")
+	assert.JSONEq(`{"id":5,"error":"meta and content are mutually exclusive."}`,
+		lines[4], "option error")
+	assert.JSONEq(`{"id":6,"error":"Request has neither source nor path."}`,
+		lines[5], "empty request")
+	assert.Regexp(`^\{"id":null,"error":"Invalid request: .*bogus.*"\}$`,
+		lines[6], "unknown field")
+	assert.Regexp(`^\{"id":null,"error":"Invalid request: .*"\}$`,
+		lines[7], "invalid JSON")
+	assert.JSONEq(`{"id":9,"result":[{"Title":"A"},{"Title":"B"}]}`,
+		lines[8], "multi")
+
+}
@@ -0,0 +1,128 @@
+// cmd_query.go - the "query" subcommand.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Query loads the Collection in the Options' Dir, filters, sorts and groups
+// it according to the other options, and prints the documents found.  The
+// documents are printed as a list, or if grouped, as a map of lists.
+//
+// Files that fail to parse are reported, and unless the Force option is set
+// the command fails; otherwise they are simply left out.  As with Run, if a
+// Where option is set and no documents match, a CMD_NO_MATCH error is
+// returned after printing.
+func (c *Cmd) Query() error {
+
+	preds, err := c.queryPredicates()
+	if err != nil {
+		return CmdError{Code: CMD_OPTIONS_ERROR, Err: err}
+	}
+
+	coll, err := c.newParser().LoadCollection(os.DirFS(c.Options.Dir), ".")
+	if err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err, File: c.Options.Dir}
+	}
+	if len(coll.Errors) > 0 {
+		if !c.Options.Force {
+			return CmdError{
+				Code:   CMD_PARSE_ERROR,
+				Err:    coll.Errors[0],
+				File:   c.Options.Dir,
+				Silent: c.Options.Silent,
+			}
+		}
+		if !c.Options.Silent {
+			for _, err := range coll.Errors {
+				fmt.Fprintln(c.Stderr, err.Error())
+			}
+		}
+	}
+
+	coll = coll.Filter(preds...)
+	if c.Options.Sort != "" {
+		coll = coll.Sort(c.Options.Sort, c.Options.Reverse)
+	}
+
+	var src interface{}
+	if c.Options.Group == "" {
+		src = c.listSource(coll.Docs)
+	} else {
+		grouper := ByKey(c.Options.Group)
+		if strings.HasSuffix(c.Options.Group, ":year") {
+			grouper = ByYear(strings.TrimSuffix(c.Options.Group, ":year"))
+		}
+		groups := map[string]interface{}{}
+		for name, group := range coll.GroupBy(grouper) {
+			groups[name] = c.listSource(group.Docs)
+		}
+		src = groups
+	}
+	if err := c.printSource(src); err != nil {
+		return err
+	}
+	if c.Options.Where != "" && coll.Len() == 0 {
+		return noMatchError()
+	}
+	return nil
+}
+
+// listSource returns the data structures to be serialized for the results.
+func (c *Cmd) listSource(results []*ParseResult) []interface{} {
+
+	list := make([]interface{}, len(results))
+	for i, res := range results {
+		list[i] = c.resultSource(res)
+	}
+	return list
+}
+
+// queryPredicates returns the Predicates for the query options.
+func (c *Cmd) queryPredicates() ([]Predicate, error) {
+
+	preds := []Predicate{}
+	if c.Options.Tag != "" {
+		preds = append(preds, HasTag(c.Options.Tag))
+	}
+	if c.Options.Match != "" {
+		eq := strings.IndexByte(c.Options.Match, '=')
+		if eq < 1 {
+			return nil, errors.New("--match must be of the form KEY=VAL.")
+		}
+		key, val := c.Options.Match[:eq], c.Options.Match[eq+1:]
+		equals, contains := Equals(key, val), Contains(key, val)
+		preds = append(preds, func(res *ParseResult) bool {
+			if _, ok := metaValue(res.Meta, key).([]interface{}); ok {
+				return contains(res)
+			}
+			return equals(res)
+		})
+	}
+	if c.Options.Since != "" || c.Options.Until != "" {
+		since, ok := metaTime(c.Options.Since)
+		if !ok && c.Options.Since != "" {
+			return nil, errors.New("Invalid date for --since: " +
+				c.Options.Since)
+		}
+		until, ok := metaTime(c.Options.Until)
+		if !ok && c.Options.Until != "" {
+			return nil, errors.New("Invalid date for --until: " +
+				c.Options.Until)
+		}
+		preds = append(preds, DateRange("Date", since, until))
+	}
+	if c.Options.Where != "" {
+		pred, err := Where(c.Options.Where)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+	return preds, nil
+}
@@ -0,0 +1,214 @@
+// cmd_query_test.go
+
+package frostedmd_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_Query(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"testing",
+		"query",
+		"--tag=golang",
+		"--match=Weight=2",
+		"--since=2024-01-01",
+		"--until=2024-12-31",
+		"--sort=Date",
+		"--reverse",
+		"--group=Date:year",
+		"somedir",
+	}
+	exp := &frostedmd.CmdOptions{
+		Format:  "json",
+		Command: "query",
+		Dir:     "somedir",
+		Tag:     "golang",
+		Match:   "Weight=2",
+		Since:   "2024-01-01",
+		Until:   "2024-12-31",
+		Sort:    "Date",
+		Reverse: true,
+		Group:   "Date:year",
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+}
+
+func queryTitles(t *testing.T, out string) []string {
+
+	var list []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for _, mm := range list {
+		titles = append(titles, mm["Title"].(string))
+	}
+	return titles
+}
+
+func Test_Query(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		opts frostedmd.CmdOptions
+		exp  []string
+	}{
+		{frostedmd.CmdOptions{}, []string{"Alpha", "Beta", "Gamma"}},
+		{frostedmd.CmdOptions{Tag: "golang"}, []string{"Alpha", "Gamma"}},
+		{frostedmd.CmdOptions{Match: "Tags=rust"}, []string{"Beta"}},
+		{frostedmd.CmdOptions{Match: "Weight=10"}, []string{"Beta"}},
+		{frostedmd.CmdOptions{Since: "2024-01", Until: "2024-03"},
+			[]string{"Alpha"}},
+		{frostedmd.CmdOptions{Sort: "Weight"},
+			[]string{"Gamma", "Alpha", "Beta"}},
+		{frostedmd.CmdOptions{Sort: "Date", Reverse: true},
+			[]string{"Gamma", "Alpha", "Beta"}},
+	} {
+		opts := tc.opts
+		opts.Command = "query"
+		opts.Dir = filepath.Join("test", "collection")
+		opts.MetaOnly = true
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &opts
+		rec := testig.NewOutputRecorder()
+		cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+		err := cmd.Query()
+		if assert.Nil(err, "no error for %+v", tc.opts) {
+			assert.Equal(tc.exp, queryTitles(t, rec.StdoutString()),
+				"titles as expected for %+v", tc.opts)
+		}
+	}
+
+}
+
+func Test_Query_Group(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command:  "query",
+		Dir:      filepath.Join("test", "collection"),
+		MetaOnly: true,
+		Group:    "Date:year",
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Query()
+	if assert.Nil(err, "no error") {
+		var groups map[string][]map[string]interface{}
+		assert.Nil(json.Unmarshal([]byte(rec.StdoutString()), &groups),
+			"output is valid JSON")
+		assert.Equal(2, len(groups["2024"]), "two docs in 2024")
+		assert.Equal(1, len(groups["2023"]), "one doc in 2023")
+	}
+
+}
+
+func Test_Query_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		opts frostedmd.CmdOptions
+		code int
+	}{
+		{frostedmd.CmdOptions{Dir: "test", Match: "nope"},
+			frostedmd.CMD_OPTIONS_ERROR},
+		{frostedmd.CmdOptions{Dir: "test", Since: "yesterday"},
+			frostedmd.CMD_OPTIONS_ERROR},
+		{frostedmd.CmdOptions{Dir: "test", Until: "tomorrow"},
+			frostedmd.CMD_OPTIONS_ERROR},
+		{frostedmd.CmdOptions{Dir: "no-such-dir"},
+			frostedmd.CMD_FILE_ERROR},
+		{frostedmd.CmdOptions{Dir: "test"}, // test/broken.md
+			frostedmd.CMD_PARSE_ERROR},
+	} {
+		opts := tc.opts
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &opts
+		err := cmd.Query()
+		if assert.Error(err, "error for %+v", tc.opts) {
+			if assert.IsType(frostedmd.CmdError{}, err) {
+				e, _ := err.(frostedmd.CmdError)
+				assert.Equal(tc.code, e.Code, "code for %+v", tc.opts)
+			}
+		}
+	}
+
+}
+
+func Test_Query_Force(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command:  "query",
+		Dir:      "test",
+		MetaOnly: true,
+		Force:    true,
+		Tag:      "golang",
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Query()
+	if assert.Nil(err, "no error with Force") {
+		titles := queryTitles(t, rec.StdoutString())
+		assert.Contains(titles, "Alpha", "good docs listed")
+		assert.Contains(titles, "Gamma", "good docs listed in subdirs")
+		assert.Regexp("broken.md: ", rec.StderrString(), "error printed")
+	}
+
+}
+
+func Test_Query_Where(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for where, exp := range map[string][]string{
+		"Weight > 1 and Tags contains golang": {"Alpha"},
+		"Weight > 100":                        {},
+	} {
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &frostedmd.CmdOptions{
+			Command: "query",
+			Dir:     filepath.Join("test", "collection"),
+			Where:   where,
+			Select:  []string{"Title"},
+		}
+		rec := testig.NewOutputRecorder()
+		cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+		err := cmd.Query()
+		assert.Equal(exp, queryTitles(t, rec.StdoutString()),
+			"titles as expected for %s", where)
+		if len(exp) > 0 {
+			assert.Nil(err, "no error for %s", where)
+		} else if assert.Error(err, "error for %s", where) {
+			e, _ := err.(frostedmd.CmdError)
+			assert.Equal(frostedmd.CMD_NO_MATCH, e.Code, "no-match code")
+		}
+	}
+
+}
@@ -0,0 +1,79 @@
+// cmd_serve.go - the "serve" subcommand.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultListenAddr is the address on which the server subcommands listen
+// if the Listen option is not set.
+const DefaultListenAddr = "localhost:8080"
+
+// Serve serves a Preview of the Options' Dir over HTTP until the context is
+// done, with pages rendered as with Build, and reloaded in the browser when
+// files change.  The address served is printed to Stdout unless the Test
+// option is set; errors in watching Dir are printed to Stderr unless Silent.
+func (c *Cmd) Serve(ctx context.Context) error {
+
+	if _, err := os.Stat(c.Options.Dir); err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err}
+	}
+	builder, err := c.newBuilder()
+	if err != nil {
+		return err
+	}
+	preview := &Preview{FS: os.DirFS(c.Options.Dir), Builder: builder}
+	builder.Parser.FS = preview.FS
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go preview.Watch(ctx, c.Options.Interval, func(err error) {
+		if !c.Options.Silent {
+			fmt.Fprintln(c.Stderr, err.Error())
+		}
+	})
+	return c.listenAndServe(ctx, preview, func(addr net.Addr) {
+		fmt.Fprintf(c.Stdout, "Serving %s at http://%s/\n", c.Options.Dir,
+			addr)
+	})
+}
+
+// listenAndServe serves the handler on the Listen address until the context
+// is done.  Once listening, the started function is called with the actual
+// address, unless the Test option is set.
+func (c *Cmd) listenAndServe(ctx context.Context, h http.Handler, started func(net.Addr)) error {
+
+	addr := c.Options.Listen
+	if addr == "" {
+		addr = DefaultListenAddr
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return CmdError{Code: CMD_SERVER_ERROR, Err: err}
+	}
+	if !c.Options.Test {
+		started(ln.Addr())
+	}
+
+	server := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close() // event streams never finish on their own
+	}()
+	err = server.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return CmdError{Code: CMD_SERVER_ERROR, Err: err}
+}
@@ -0,0 +1,115 @@
+// cmd_serve_test.go
+
+package frostedmd_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_Serve(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{"testing", "serve", "--listen=:9999", "srcdir"}
+	exp := &frostedmd.CmdOptions{
+		Format:  "json",
+		Command: "serve",
+		Dir:     "srcdir",
+		Listen:  ":9999",
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+}
+
+// freeAddr returns a local address that was free a moment ago.
+func freeAddr(t *testing.T) string {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func Test_Serve(t *testing.T) {
+
+	assert := assert.New(t)
+
+	addr := freeAddr(t)
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command: "serve",
+		Dir:     filepath.Join("test", "collection"),
+		Listen:  addr,
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- cmd.Serve(ctx) }()
+
+	var body []byte
+	for i := 0; i < 100; i++ {
+		resp, err := http.Get("http://" + addr + "/alpha.html")
+		if err == nil {
+			body, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Contains(string(body), "<title>Alpha</title>", "page served")
+
+	cancel()
+	assert.Nil(<-done, "no error at end")
+	assert.Equal("Serving test/collection at http://"+addr+"/\n",
+		rec.StdoutString(), "address printed")
+
+}
+
+func Test_Serve_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		opts frostedmd.CmdOptions
+		code int
+	}{
+		{frostedmd.CmdOptions{Dir: "no/such/dir"}, frostedmd.CMD_FILE_ERROR},
+		{frostedmd.CmdOptions{Dir: "test", Template: "nope/[x"},
+			frostedmd.CMD_TEMPLATE_ERROR},
+		{frostedmd.CmdOptions{Dir: "test", Listen: "nope:-1"},
+			frostedmd.CMD_SERVER_ERROR},
+	} {
+		opts := tc.opts
+		opts.Command = "serve"
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &opts
+
+		err := cmd.Serve(context.Background())
+		if assert.Error(err, "error for %+v", tc.opts) {
+			if cmdErr, ok := err.(frostedmd.CmdError); assert.True(ok,
+				"CmdError for %+v", tc.opts) {
+				assert.Equal(tc.code, cmdErr.Code, "code for %+v", tc.opts)
+			}
+		}
+	}
+
+}
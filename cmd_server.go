@@ -0,0 +1,307 @@
+// cmd_server.go - the "server" subcommand, an HTTP API for parsing.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxRequestBytes is the largest parse request body accepted by the
+// server subcommand if the MaxSize option is not set.
+const DefaultMaxRequestBytes = 1 << 20
+
+// ParseRequest is the JSON form of a request to the parse API: the Markdown
+// source and the options with which to parse and print it.
+type ParseRequest struct {
+	Source  string              `json:"source"`
+	Options ParseRequestOptions `json:"options"`
+}
+
+// ParseRequestOptions are the options of a ParseRequest, named after the
+// equivalent command-line options.  For raw Markdown requests they are
+// given as query parameters instead, e.g. "/parse?meta&indent".
+//
+// The parser options, which have no command-line equivalents, set the
+// Parser fields of the same names: "tables" sets ExtractTables, "sections"
+// the SectionLevel, "wikilinks" WikiLinks and "metaatend" MetaAtEnd.
+type ParseRequestOptions struct {
+	Meta          bool     `json:"meta"`
+	Content       bool     `json:"content"`
+	NoBase64      bool     `json:"nobase64"`
+	Indent        bool     `json:"indent"`
+	PlainMarkdown bool     `json:"plainmd"`
+	Document      bool     `json:"document"`
+	Style         string   `json:"style"`
+	Multi         bool     `json:"multi"`
+	Separator     string   `json:"separator"`
+	Select        []string `json:"select"`
+	Tables        bool     `json:"tables"`
+	Sections      int      `json:"sections"`
+	WikiLinks     bool     `json:"wikilinks"`
+	MetaAtEnd     bool     `json:"metaatend"`
+}
+
+// parseAPIError is the JSON body of an error response.  Line is the line
+// of a meta error in the source, if known.
+type parseAPIError struct {
+	Error string `json:"error"`
+	Line  int    `json:"line,omitempty"`
+}
+
+// Server serves the parse API over HTTP until the context is done; see
+// ParseHandler.  The address served is printed to Stdout unless the Test
+// option is set.
+func (c *Cmd) Server(ctx context.Context) error {
+
+	return c.listenAndServe(ctx, c.ParseHandler(), func(addr net.Addr) {
+		fmt.Fprintf(c.Stdout, "Serving parse API at http://%s/\n", addr)
+	})
+}
+
+// ParseHandler returns the http.Handler for the parse API, with these
+// endpoints:
+//
+//	POST /parse   parse the request body and return the output
+//	GET /health   return {"status":"ok","version":VERSION}
+//
+// The body of a parse request is either raw Markdown, with options in the
+// query, or if its Content-Type is "application/json" a ParseRequest.  The
+// response is what the command would print for the source with the same
+// options, as JSON, or as HTML for the content or plainmd options.  The
+// Cache and Template options of the Cmd apply to all requests.
+//
+// Errors are returned as JSON objects with an "error" message: invalid
+// options are a 400 Bad Request, bodies larger than the MaxSize option a
+// 413 Request Entity Too Large, and parse errors a 422 Unprocessable
+// Entity, with the "line" of the error if it is in the Meta Block.
+func (c *Cmd) ParseHandler() http.Handler {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/parse", c.serveParse)
+	mux.HandleFunc("/health", c.serveHealth)
+	return mux
+}
+
+// serveHealth reports that the server is up.
+func (c *Cmd) serveHealth(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeAPIError(w, http.StatusMethodNotAllowed,
+			errors.New("Method not allowed."))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "ok",
+		"version": c.Version,
+	})
+}
+
+// serveParse parses the request body as the command would parse a file.
+func (c *Cmd) serveParse(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeAPIError(w, http.StatusMethodNotAllowed,
+			errors.New("Method not allowed."))
+		return
+	}
+	max := c.Options.MaxSize
+	if max <= 0 {
+		max = DefaultMaxRequestBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if int64(len(body)) > max {
+		writeAPIError(w, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("Request body exceeds %d bytes.", max))
+		return
+	}
+
+	req := &ParseRequest{Source: string(body)}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		req = &ParseRequest{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(req); err != nil {
+			writeAPIError(w, http.StatusBadRequest,
+				fmt.Errorf("Invalid JSON request: %s", err))
+			return
+		}
+	} else if err := req.Options.setQuery(r.URL.Query()); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	opts, err := c.requestOptions(&req.Options)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	var out bytes.Buffer
+	sub := &Cmd{
+		Name:    c.Name,
+		Version: c.Version,
+		Options: opts,
+		Exit:    c.Exit,
+		Stdin:   strings.NewReader(req.Source),
+		Stdout:  &out,
+		Stderr:  io.Discard,
+	}
+	if err := sub.ParseFile(); err != nil {
+		apiErr := parseAPIError{Error: err.Error()}
+		var me MetaError
+		if errors.As(err, &me) {
+			apiErr.Line = me.Line
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, apiErr)
+		return
+	}
+	if sub.Result != nil {
+		sub.Result = jsonResult(sub.Result)
+	}
+	for i, res := range sub.Results {
+		sub.Results[i] = jsonResult(res)
+	}
+	if err := sub.PrintResult(); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if opts.ContentOnly || opts.PlainMarkdown {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Write(out.Bytes())
+}
+
+// requestOptions returns the CmdOptions for a parse request, checking them
+// as SetOptions would.
+func (c *Cmd) requestOptions(ro *ParseRequestOptions) (*CmdOptions, error) {
+
+	if ro.Meta && ro.Content {
+		return nil, errors.New("meta and content are mutually exclusive.")
+	}
+	if ro.Content && ro.Select != nil {
+		return nil, errors.New("select and content are mutually exclusive.")
+	}
+	if ro.Sections < 0 {
+		return nil, errors.New("sections must not be negative.")
+	}
+	if ro.Document && c.Options.Template != "" {
+		return nil, errors.New(
+			"document is not available with the server's template.")
+	}
+	if ro.PlainMarkdown && (ro.Meta || ro.Content || ro.NoBase64 ||
+		ro.Indent || ro.Document || ro.Style != "" || ro.Multi ||
+		ro.Separator != "" || ro.Select != nil || ro.Tables ||
+		ro.Sections != 0 || ro.WikiLinks || ro.MetaAtEnd) {
+		return nil, errors.New("plainmd excludes other options.")
+	}
+	format := "json"
+	if ro.PlainMarkdown {
+		format = ""
+	}
+	return &CmdOptions{
+		Format:        format,
+		Indent:        ro.Indent,
+		NoBase64:      ro.NoBase64,
+		ContentOnly:   ro.Content,
+		MetaOnly:      ro.Meta,
+		PlainMarkdown: ro.PlainMarkdown,
+		Multi:         ro.Multi,
+		Separator:     ro.Separator,
+		Document:      ro.Document,
+		Style:         ro.Style,
+		Template:      c.Options.Template,
+		Cache:         c.Options.Cache,
+		Select:        ro.Select,
+		Tables:        ro.Tables,
+		SectionLevel:  ro.Sections,
+		WikiLinks:     ro.WikiLinks,
+		MetaAtEnd:     ro.MetaAtEnd,
+	}, nil
+}
+
+// setQuery sets the options from URL query parameters.  Boolean options
+// with no value are true.
+func (ro *ParseRequestOptions) setQuery(query url.Values) error {
+
+	bools := map[string]*bool{
+		"meta":      &ro.Meta,
+		"content":   &ro.Content,
+		"nobase64":  &ro.NoBase64,
+		"indent":    &ro.Indent,
+		"plainmd":   &ro.PlainMarkdown,
+		"document":  &ro.Document,
+		"multi":     &ro.Multi,
+		"tables":    &ro.Tables,
+		"wikilinks": &ro.WikiLinks,
+		"metaatend": &ro.MetaAtEnd,
+	}
+	for key, values := range query {
+		value := values[len(values)-1]
+		if b, ok := bools[key]; ok {
+			if value == "" {
+				*b = true
+				continue
+			}
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("Invalid %s: %s", key, value)
+			}
+			*b = v
+			continue
+		}
+		switch key {
+		case "style":
+			ro.Style = value
+		case "separator":
+			ro.Separator = value
+		case "sections":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("Invalid %s: %s", key, value)
+			}
+			ro.Sections = n
+		case "select":
+			for _, k := range strings.Split(value, ",") {
+				ro.Select = append(ro.Select, strings.TrimSpace(k))
+			}
+		default:
+			return fmt.Errorf("Unknown option: %s", key)
+		}
+	}
+	return nil
+}
+
+// writeAPIError writes err as a JSON error response with the status code.
+func writeAPIError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, parseAPIError{Error: err.Error()})
+}
+
+// writeJSON writes v as a JSON response with the status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,241 @@
+// cmd_server_test.go
+
+package frostedmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_CmdError_Unwrap(t *testing.T) {
+
+	assert := assert.New(t)
+
+	src := errors.New("oops")
+	err := frostedmd.CmdError{Err: src, File: "x.md"}
+	assert.True(errors.Is(err, src), "source error found")
+
+}
+
+func Test_SetOptions_Server(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{"testing", "server", "--listen=:9999",
+		"--max-size=100"}
+	exp := &frostedmd.CmdOptions{
+		Format:  "json",
+		Command: "server",
+		Listen:  ":9999",
+		MaxSize: 100,
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+
+	os.Args = []string{"testing", "server", "--max-size=lots"}
+	err = cmd.SetOptions()
+	if assert.Error(err, "error for bad size") {
+		assert.Equal("Invalid --max-size: lots", err.Error(), "message")
+	}
+}
+
+// postParse posts body to the parse API and returns the response status,
+// content type and body.
+func postParse(cmd *frostedmd.Cmd, target, contentType, body string) (int, string, string) {
+
+	req := httptest.NewRequest("POST", target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	cmd.ParseHandler().ServeHTTP(w, req)
+	return w.Code, w.Header().Get("Content-Type"), w.Body.String()
+}
+
+func newServerCmd() *frostedmd.Cmd {
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{Command: "server"}
+	return cmd
+}
+
+func Test_ParseHandler_Raw(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := newServerCmd()
+	src := "# Hello\n\n    Tags: [a, b]\n    More: {Nested: yes}\n\nWorld.\n"
+
+	code, ctype, body := postParse(cmd, "/parse?meta", "text/markdown", src)
+	assert.Equal(200, code, "status")
+	assert.Equal("application/json", ctype, "content type")
+	assert.JSONEq(`{"Title":"Hello","Tags":["a","b"],"More":{"Nested":true}}`,
+		body, "meta returned")
+
+	code, _, body = postParse(cmd, "/parse?nobase64=true", "", src)
+	assert.Equal(200, code, "status")
+	res := map[string]interface{}{}
+	if assert.Nil(json.Unmarshal([]byte(body), &res), "valid JSON") {
+		assert.Equal("<h1>Hello</h1>\n\n<p>World.</p>\n", res["content"],
+			"content as string")
+	}
+
+	code, ctype, body = postParse(cmd, "/parse?content", "", src)
+	assert.Equal(200, code, "status")
+	assert.Equal("text/html; charset=utf-8", ctype, "content type")
+	assert.Equal("<h1>Hello</h1>\n\n<p>World.</p>\n\n", body,
+		"content only")
+
+	code, _, body = postParse(cmd, "/parse?meta&indent", "", src)
+	assert.Equal(200, code, "status")
+	assert.Contains(body, "\n  \"Title\": \"Hello\"", "indented")
+
+}
+
+func Test_ParseHandler_JSON(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := newServerCmd()
+	req := `{"source": "# One\n\nA.\n\n+++\n\n# Two\n\nB.\n",
+		"options": {"multi": true, "select": ["Title"]}}`
+	code, ctype, body := postParse(cmd, "/parse", "application/json", req)
+	assert.Equal(200, code, "status")
+	assert.Equal("application/json", ctype, "content type")
+	assert.JSONEq(`[{"Title":"One"},{"Title":"Two"}]`, body, "selected")
+
+}
+
+func Test_ParseHandler_ParserOptions(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := newServerCmd()
+	src := "Intro.\n\n## One\n\n| A |\n|---|\n| 1 |\n\n" +
+		"## Two\n\nSee [[One]].\n\n    Title: Last\n"
+
+	code, _, body := postParse(cmd,
+		"/parse?nobase64&tables&sections=2&wikilinks&metaatend", "", src)
+	assert.Equal(200, code, "status")
+	res := struct {
+		Meta     map[string]interface{}
+		Content  string
+		Tables   []interface{}
+		Sections []map[string]interface{}
+	}{}
+	if assert.Nil(json.Unmarshal([]byte(body), &res), "valid JSON") {
+		assert.Equal("Last", res.Meta["Title"], "meta at end")
+		assert.Equal(1, len(res.Tables), "tables extracted")
+		assert.Equal(3, len(res.Sections), "sections split")
+		assert.Contains(res.Content, `<a href="one.html">One</a>`,
+			"wiki link expanded")
+	}
+
+	req := `{"source": "# Hi\n\n| A |\n|---|\n| 1 |\n",
+		"options": {"meta": true, "tables": true, "sections": 1}}`
+	code, _, body = postParse(cmd, "/parse", "application/json", req)
+	assert.Equal(200, code, "status")
+	assert.JSONEq(`{"Title":"Hi"}`, body, "parser options in JSON")
+
+}
+
+func Test_ParseHandler_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := newServerCmd()
+	cmd.Options.MaxSize = 50
+
+	for _, tc := range []struct {
+		target, ctype, body string
+		code                int
+		exp                 string
+	}{
+		{"/parse?meta&content", "", "# Hi\n", 400,
+			`{"error":"meta and content are mutually exclusive."}`},
+		{"/parse?plainmd&meta", "", "# Hi\n", 400,
+			`{"error":"plainmd excludes other options."}`},
+		{"/parse?meta=maybe", "", "# Hi\n", 400,
+			`{"error":"Invalid meta: maybe"}`},
+		{"/parse?sections=two", "", "# Hi\n", 400,
+			`{"error":"Invalid sections: two"}`},
+		{"/parse?sections=-1", "", "# Hi\n", 400,
+			`{"error":"sections must not be negative."}`},
+		{"/parse?plainmd&tables", "", "# Hi\n", 400,
+			`{"error":"plainmd excludes other options."}`},
+		{"/parse?bogus", "", "# Hi\n", 400,
+			`{"error":"Unknown option: bogus"}`},
+		{"/parse", "application/json", `{"source":"x","extra":1}`, 400,
+			`{"error":"Invalid JSON request: json: unknown field \"extra\""}`},
+		{"/parse", "", strings.Repeat("x", 51), 413,
+			`{"error":"Request body exceeds 50 bytes."}`},
+		{"/parse", "", "# Hi\n\n    Foo: [\n\nBar.\n", 422, ""},
+	} {
+		code, ctype, body := postParse(cmd, tc.target, tc.ctype, tc.body)
+		assert.Equal(tc.code, code, "status for %s", tc.target)
+		assert.Equal("application/json", ctype, "type for %s", tc.target)
+		if tc.exp != "" {
+			assert.JSONEq(tc.exp, body, "body for %s", tc.target)
+		}
+	}
+
+	_, _, body := postParse(cmd, "/parse", "", "# Hi\n\n    Foo: [\n\nBar.\n")
+	res := map[string]interface{}{}
+	if assert.Nil(json.Unmarshal([]byte(body), &res), "valid JSON") {
+		assert.Regexp("^yaml", res["error"], "yaml error")
+		assert.Equal(float64(3), res["line"], "line of meta error")
+	}
+
+	req := httptest.NewRequest("GET", "/parse", nil)
+	w := httptest.NewRecorder()
+	cmd.ParseHandler().ServeHTTP(w, req)
+	assert.Equal(405, w.Code, "GET not allowed")
+	assert.Equal("POST", w.Header().Get("Allow"), "Allow header")
+
+}
+
+func Test_Server(t *testing.T) {
+
+	assert := assert.New(t)
+
+	addr := freeAddr(t)
+	cmd := newServerCmd()
+	cmd.Options.Listen = addr
+	cmd.Options.Test = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- cmd.Server(ctx) }()
+
+	var body []byte
+	for i := 0; i < 100; i++ {
+		resp, err := http.Get("http://" + addr + "/health")
+		if err == nil {
+			body, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.JSONEq(`{"status":"ok","version":"1.1.0"}`, string(body),
+		"health reported")
+
+	cancel()
+	assert.Nil(<-done, "no error at end")
+
+}
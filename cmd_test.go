@@ -905,3 +905,501 @@ func Test_Run_E2E(t *testing.T) {
 
 	}
 }
+
+func Test_SetOptions_Multi(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"testing",
+		"--multi",
+		"--separator=##",
+		"somefile",
+	}
+	exp := &frostedmd.CmdOptions{
+		File:      "somefile",
+		Format:    "json",
+		Multi:     true,
+		Separator: "##",
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+}
+
+func Test_ParseFile_Multi(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join("test", "multi.md")
+	for _, sep := range []string{"", "+++", "#"} {
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &frostedmd.CmdOptions{
+			File:      file,
+			Multi:     true,
+			Separator: sep,
+		}
+		err := cmd.ParseFile()
+		if assert.Nil(err, "no error from ParseFile") {
+			assert.Nil(cmd.Result, "Result not set")
+			if assert.Equal(2, len(cmd.Results), "Results set") {
+				assert.Equal("First Entry", cmd.Results[0].Meta["Title"],
+					"first title for separator %q", sep)
+				assert.Equal("Second Entry", cmd.Results[1].Meta["Title"],
+					"second title for separator %q", sep)
+			}
+		}
+	}
+}
+
+func Test_ParseFile_Multi_ParseError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join("test", "broken.md")
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{File: file, Multi: true}
+	err := cmd.ParseFile()
+	if assert.Error(err) {
+		assert.Regexp("^test.*broken.*Document 1: yaml", err.Error(),
+			"error as expected")
+	}
+	assert.Equal(1, len(cmd.Results), "partial Results set")
+}
+
+func Test_PrintResult_Results_JSON(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Results = []*frostedmd.ParseResult{
+		{Meta: map[string]interface{}{"foo": 1}, Content: []byte("one")},
+		{Meta: map[string]interface{}{"foo": 2}, Content: []byte("two")},
+	}
+	exp := `[{"content":"one","meta":{"foo":1}},` +
+		`{"content":"two","meta":{"foo":2}}]
+`
+	cmd.Options = &frostedmd.CmdOptions{NoBase64: true}
+
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.PrintResult()
+	assert.Nil(err, "no error on PrintResult")
+	assert.Equal(exp, rec.StdoutString(), "json list on stdout")
+	assert.Equal("", rec.StderrString(), "no standard error")
+
+}
+
+func Test_PrintResult_Results_ContentOnly(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Results = []*frostedmd.ParseResult{
+		{Content: []byte("one")},
+		{Content: []byte("two")},
+	}
+	cmd.Options = &frostedmd.CmdOptions{ContentOnly: true}
+
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.PrintResult()
+	assert.Nil(err, "no error on PrintResult")
+	assert.Equal("one\ntwo\n", rec.StdoutString(), "content on stdout")
+
+}
+
+func Test_ParseFile_Document(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join("test", "simple.md")
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		File:     file,
+		Document: true,
+		Style:    "none",
+	}
+	err := cmd.ParseFile()
+	if assert.Nil(err, "no error from ParseFile") {
+		assert.Equal("FMD FTW", cmd.Result.Meta["Title"], "meta as usual")
+		assert.Regexp("^<!DOCTYPE html>\n", string(cmd.Result.Content),
+			"content is a document")
+		assert.Contains(string(cmd.Result.Content),
+			"<title>FMD FTW</title>", "title set")
+	}
+}
+
+func Test_ParseFile_Document_Multi(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join("test", "multi.md")
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		File:     file,
+		Document: true,
+		Multi:    true,
+	}
+	err := cmd.ParseFile()
+	if assert.Nil(err, "no error from ParseFile") {
+		for _, res := range cmd.Results {
+			assert.Regexp("^<!DOCTYPE html>\n", string(res.Content),
+				"content is a document")
+		}
+	}
+}
+
+func Test_SetOptions_DocumentTemplateContradiction(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"testing",
+		"--document",
+		"--template=foo.tmpl",
+		"somefile",
+	}
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Error(err, "error set") {
+		assert.Equal("--document and --template are mutually exclusive.",
+			err.Error(), "error string as expected")
+	}
+
+}
+
+func Test_ParseFile_Template(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join("test", "simple.md")
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		File:     file,
+		Template: filepath.Join("test", "templates", "*.tmpl"),
+	}
+	exp := "<h1>Other: FMD FTW</h1>\n1:Simple FMD\n\n"
+	err := cmd.ParseFile()
+	if assert.Nil(err, "no error from ParseFile") {
+		// other.tmpl sorts first
+		assert.Equal(exp, string(cmd.Result.Content), "template used")
+		if assert.NotNil(cmd.Result.File, "File set") {
+			assert.Equal(file, cmd.Result.File.Path, "File Path set")
+			assert.Equal("simple.md", cmd.Result.File.Name, "File Name set")
+		}
+	}
+}
+
+func Test_ParseFile_TemplateErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, tmpl := range []string{"no-such-*.tmpl", "test/*.md"} {
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &frostedmd.CmdOptions{
+			File:     filepath.Join("test", "simple.md"),
+			Template: tmpl,
+		}
+		err := cmd.ParseFile()
+		if assert.Error(err, "error for %s", tmpl) {
+			if assert.IsType(frostedmd.CmdError{}, err) {
+				e, _ := err.(frostedmd.CmdError)
+				assert.Equal(frostedmd.CMD_TEMPLATE_ERROR, e.Code,
+					"error code is 'template'")
+			}
+		}
+	}
+}
+
+func Test_SetOptions_WhereSelect(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"testing",
+		`--where=Tags contains "golang"`,
+		"--select=Title, Tags",
+		"somefile",
+	}
+	exp := &frostedmd.CmdOptions{
+		File:   "somefile",
+		Format: "json",
+		Where:  `Tags contains "golang"`,
+		Select: []string{"Title", "Tags"},
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+}
+
+func Test_SetOptions_WhereSelectErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, args := range [][]string{
+		{"--where=Tags contains", "somefile"},
+		{"--select=Title", "--content", "somefile"},
+		{"--select=Title", "--plainmd", "somefile"},
+	} {
+		os.Args = append([]string{"testing"}, args...)
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		err := cmd.SetOptions()
+		if assert.Error(err, "error for %v", args) {
+			if assert.IsType(frostedmd.CmdError{}, err) {
+				e, _ := err.(frostedmd.CmdError)
+				assert.Equal(frostedmd.CMD_OPTIONS_ERROR, e.Code,
+					"error code is 'options'")
+			}
+		}
+	}
+}
+
+func Test_Run_WhereSelect(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"test",
+		`--where=Tags contains "golang"`,
+		"--select=Title,Missing",
+		filepath.Join("test", "simple.md"),
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Run()
+	assert.Nil(err, "no error on Run")
+	assert.Equal(`{"Title":"FMD FTW"}`+"\n", rec.StdoutString(),
+		"selected meta on stdout")
+
+}
+
+func Test_Run_WhereNoMatch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"test",
+		`--where=Tags contains "rust"`,
+		filepath.Join("test", "simple.md"),
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	err := cmd.Run()
+	if assert.Error(err, "error on Run") {
+		if assert.IsType(frostedmd.CmdError{}, err) {
+			e, _ := err.(frostedmd.CmdError)
+			assert.Equal(frostedmd.CMD_NO_MATCH, e.Code,
+				"error code is 'no match'")
+			assert.True(e.Silent, "error is silent")
+		}
+	}
+	assert.Equal("", rec.StdoutString(), "nothing on stdout")
+
+}
+
+func Test_FilterResults_Multi(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		File:  filepath.Join("test", "multi.md"),
+		Multi: true,
+		Where: `Tags contains one`,
+	}
+	err := cmd.ParseFile()
+	if assert.Nil(err, "no error from ParseFile") {
+		matched, err := cmd.FilterResults()
+		assert.Nil(err, "no error from FilterResults")
+		assert.True(matched, "matched")
+		if assert.Equal(1, len(cmd.Results), "one result left") {
+			assert.Equal("First Entry", cmd.Results[0].Meta["Title"],
+				"matching result left")
+		}
+	}
+
+	cmd.Options.Where = "Tags contains"
+	_, err = cmd.FilterResults()
+	assert.Error(err, "error for bad expression")
+
+}
+
+func Test_SetOptions_Files(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		args []string
+		exp  *frostedmd.CmdOptions
+	}{
+		{[]string{"a.md", "b.md"}, &frostedmd.CmdOptions{
+			Format: "json",
+			Files:  []string{"a.md", "b.md"},
+		}},
+		{[]string{"-r", "--ndjson", "docs"}, &frostedmd.CmdOptions{
+			Format:    "json",
+			Files:     []string{"docs"},
+			Recursive: true,
+			NDJSON:    true,
+		}},
+		{[]string{}, &frostedmd.CmdOptions{Format: "json"}},
+	} {
+		os.Args = append([]string{"testing"}, tc.args...)
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		err := cmd.SetOptions()
+		if assert.Nil(err, "no error for %v", tc.args) {
+			assert.Equal(tc.exp, cmd.Options, "options for %v", tc.args)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-r"},
+		{"--ndjson", "-y", "a.md"},
+	} {
+		os.Args = append([]string{"testing"}, args...)
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		err := cmd.SetOptions()
+		if assert.Error(err, "error for %v", args) {
+			e, _ := err.(frostedmd.CmdError)
+			assert.Equal(frostedmd.CMD_OPTIONS_ERROR, e.Code,
+				"error code is 'options' for %v", args)
+		}
+	}
+}
+
+func Test_Run_Files(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir := filepath.Join("test", "collection")
+	alpha := filepath.Join(dir, "alpha.md")
+	beta := filepath.Join(dir, "beta.md")
+	gamma := filepath.Join(dir, "sub", "gamma.md")
+
+	for _, tc := range []struct {
+		args []string
+		exp  string
+	}{
+		{
+			[]string{"--select=Title", alpha, beta},
+			`[{"meta":{"Title":"Alpha"},"path":"` + alpha + `"},` +
+				`{"meta":{"Title":"Beta"},"path":"` + beta + `"}]` + "\n",
+		},
+		{
+			[]string{"--ndjson", "-i", "-m", "-r", dir},
+			`{"meta":{"Date":"2024-02-01","Tags":["golang","markdown"],` +
+				`"Title":"Alpha","Weight":2},"path":"` + alpha + `"}` + "\n" +
+				`{"meta":{"Date":"2023-05-01","Tags":["rust"],` +
+				`"Title":"Beta","Weight":10},"path":"` + beta + `"}` + "\n" +
+				`{"meta":{"Date":"2024-06-01","Tags":["golang"],` +
+				`"Title":"Gamma","Weight":1},"path":"` + gamma + `"}` + "\n",
+		},
+		{
+			[]string{"--ndjson", "--select=Title", alpha},
+			`{"meta":{"Title":"Alpha"},"path":"` + alpha + `"}` + "\n",
+		},
+		{
+			[]string{"-y", "--where=Weight < 2", "-r", dir},
+			"---\ncontent: |\n  <h1>Gamma</h1>\n\n  <p>The third.</p>\n" +
+				"meta:\n  Date: \"2024-06-01\"\n  Tags:\n  - golang\n" +
+				"  Title: Gamma\n  Weight: 1\npath: " + gamma + "\n\n",
+		},
+		{
+			[]string{"-c", alpha, beta},
+			"<h1>Alpha</h1>\n\n<p>The first.</p>\n\n" +
+				"<h1>Beta</h1>\n\n<p>The second.</p>\n\n",
+		},
+	} {
+		os.Args = append([]string{"test"}, tc.args...)
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		rec := testig.NewOutputRecorder()
+		cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+		err := cmd.Run()
+		assert.Nil(err, "no error on Run for %v", tc.args)
+		assert.Equal(tc.exp, rec.StdoutString(), "output for %v", tc.args)
+		assert.Equal("", rec.StderrString(), "no stderr for %v", tc.args)
+	}
+
+}
+
+func Test_Run_Files_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Without --force the first error stops everything.
+	os.Args = []string{"test", "-m", "-r", "test"}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+	err := cmd.Run()
+	if assert.Error(err, "error on Run") {
+		assert.Regexp("broken.md: ", err.Error(), "error for broken file")
+		e, _ := err.(frostedmd.CmdError)
+		assert.Equal(frostedmd.CMD_PARSE_ERROR, e.Code, "parse error code")
+	}
+	assert.Equal("", rec.StdoutString(), "no output")
+
+	// With --force the rest is printed, followed by a summary.
+	os.Args = []string{"test", "-m", "-f", "-r", "test"}
+	cmd = frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	rec = testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+	err = cmd.Run()
+	if assert.Error(err, "error on Run") {
+		assert.Regexp(`^1 of \d+ files failed.$`, err.Error(), "summary")
+		e, _ := err.(frostedmd.CmdError)
+		assert.Equal(frostedmd.CMD_PARSE_ERROR, e.Code, "parse error code")
+		assert.False(e.Force, "not forced, so the exit code is used")
+	}
+	assert.Regexp("Alpha", rec.StdoutString(), "good files printed")
+	assert.Regexp("broken.md: ", rec.StderrString(), "error printed")
+
+	// Walking a missing directory is a file error.
+	os.Args = []string{"test", "-r", "no-such-dir"}
+	cmd = frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err = cmd.Run()
+	if assert.Error(err, "error on Run") {
+		e, _ := err.(frostedmd.CmdError)
+		assert.Equal(frostedmd.CMD_FILE_ERROR, e.Code, "file error code")
+	}
+
+}
+
+func Test_Run_Cache(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cacheDir := t.TempDir()
+	dir := filepath.Join("test", "collection")
+	outputs := []string{}
+	for i := 0; i < 2; i++ {
+		os.Args = []string{"test", "--cache=" + cacheDir, "-m", "-r", dir}
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		rec := testig.NewOutputRecorder()
+		cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+		err := cmd.Run()
+		if !assert.Nil(err, "no error on Run %d", i) {
+			return
+		}
+		assert.Equal(cacheDir, cmd.Options.Cache, "cache option set")
+		outputs = append(outputs, rec.StdoutString())
+	}
+	assert.Equal(outputs[0], outputs[1], "same output from cache")
+	entries, _ := filepath.Glob(filepath.Join(cacheDir, "*", "*.gob"))
+	assert.Equal(3, len(entries), "one entry per file")
+
+}
@@ -0,0 +1,82 @@
+// cmd_watch.go - the "watch" subcommand.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Watch builds the static site in the Options' Dir into the Out directory
+// as with Build, then watches Dir for changes and rebuilds what is needed
+// until the context is done.  Errors in documents, and in reading Dir, are
+// reported to Stderr unless Silent and do not stop the watch; meta errors
+// are reported with their line numbers.  The Out directory may not be
+// inside Dir, unless it is hidden.
+func (c *Cmd) Watch(ctx context.Context) error {
+
+	if inside(c.Options.Out, c.Options.Dir) {
+		return CmdError{
+			Code: CMD_OPTIONS_ERROR,
+			Err:  errors.New("--out must not be inside the watched DIR."),
+		}
+	}
+	builder, err := c.newBuilder()
+	if err != nil {
+		return err
+	}
+
+	// Changes made while we build are seen by the first poll after.
+	src := os.DirFS(c.Options.Dir)
+	builder.Parser.FS = src
+	watcher := NewWatcher(src)
+	watcher.Interval = c.Options.Interval
+	if _, err := watcher.Poll(); err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err}
+	}
+	report, err := builder.Build(src, c.Options.Out)
+	if err != nil {
+		return CmdError{Code: CMD_FILE_ERROR, Err: err}
+	}
+	c.printReport("Built", report)
+
+	for ev := range watcher.Watch(ctx) {
+		if ev.Err == nil {
+			report, err = builder.Rebuild(src, c.Options.Out, ev.Changed)
+			if err == nil {
+				c.printReport("Rebuilt", report)
+				continue
+			}
+		} else {
+			err = ev.Err
+		}
+		if !c.Options.Silent {
+			fmt.Fprintln(c.Stderr, err.Error())
+		}
+	}
+	return nil
+}
+
+// inside returns true if the path is dir or a non-hidden path within it.
+func inside(path, dir string) bool {
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return rel == "." || !strings.HasPrefix(rel, ".")
+}
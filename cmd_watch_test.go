@@ -0,0 +1,146 @@
+// cmd_watch_test.go
+
+package frostedmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biztos/testig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_SetOptions_Watch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	os.Args = []string{
+		"testing",
+		"watch",
+		"--out=outdir",
+		"--interval=50ms",
+		"srcdir",
+	}
+	exp := &frostedmd.CmdOptions{
+		Format:   "json",
+		Command:  "watch",
+		Dir:      "srcdir",
+		Out:      "outdir",
+		Interval: 50 * time.Millisecond,
+	}
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	err := cmd.SetOptions()
+	if assert.Nil(err, "no error") {
+		assert.Equal(exp, cmd.Options, "options set as expected")
+	}
+
+	for args, msg := range map[string]string{
+		"watch srcdir":                         "--out is required for watch.",
+		"watch --out=x --interval=soon srcdir": "Invalid --interval: soon",
+		"watch --out=x --interval=-1s srcdir":  "Invalid --interval: -1s",
+	} {
+		os.Args = append([]string{"testing"}, strings.Fields(args)...)
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		err := cmd.SetOptions()
+		if assert.Error(err, "error for %s", args) {
+			assert.Equal(msg, err.Error(), "message for %s", args)
+		}
+	}
+}
+
+func Test_Watch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	src, dst := t.TempDir(), t.TempDir()
+	write := func(name, content string) {
+		err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.md", "# A\n\nFirst.\n")
+
+	cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+	cmd.Options = &frostedmd.CmdOptions{
+		Command:  "watch",
+		Dir:      src,
+		Out:      dst,
+		Style:    "none",
+		Interval: 5 * time.Millisecond,
+	}
+	rec := testig.NewOutputRecorder()
+	cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- cmd.Watch(ctx) }()
+
+	// Wait for output, since the watch is asynchronous.
+	waitFor := func(name, text string) bool {
+		for i := 0; i < 200; i++ {
+			b, _ := os.ReadFile(filepath.Join(dst, name))
+			if strings.Contains(string(b), text) {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+	assert.True(waitFor("a.html", "First."), "initial build")
+	write("b.md", "# B\n\n    x: [y\n")
+	write("a.md", "# A\n\nSecond.\n")
+	assert.True(waitFor("a.html", "Second."), "rebuilt after change")
+	write("b.md", "# B\n\nFixed.\n")
+	assert.True(waitFor("b.html", "Fixed."), "rebuilt after fix")
+
+	cancel()
+	assert.Nil(<-done, "no error at end")
+	assert.Regexp("^Built 1 pages, 1 indexes and 0 assets in .*\n"+
+		"Rebuilt ", rec.StdoutString(), "summaries printed")
+	assert.Regexp("^b.md:3: yaml: ", rec.StderrString(),
+		"error printed with position")
+
+}
+
+func Test_Watch_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		opts frostedmd.CmdOptions
+		code int
+	}{
+		{frostedmd.CmdOptions{Dir: dir, Out: filepath.Join(dir, "out")},
+			frostedmd.CMD_OPTIONS_ERROR},
+		{frostedmd.CmdOptions{Dir: dir, Out: dir},
+			frostedmd.CMD_OPTIONS_ERROR},
+		{frostedmd.CmdOptions{Dir: "test", Out: t.TempDir(),
+			Template: "nope/[x"}, frostedmd.CMD_TEMPLATE_ERROR},
+		{frostedmd.CmdOptions{Dir: filepath.Join(dir, "nope"),
+			Out: t.TempDir()}, frostedmd.CMD_FILE_ERROR},
+	} {
+		opts := tc.opts
+		opts.Command = "watch"
+		cmd := frostedmd.NewCmd("testing", "1.1.0", frostedmd.CmdUsage)
+		cmd.Options = &opts
+		rec := testig.NewOutputRecorder()
+		cmd.Stdout, cmd.Stderr = rec.Stdout, rec.Stderr
+
+		err := cmd.Watch(context.Background())
+		if assert.Error(err, "error for %+v", tc.opts) {
+			if cmdErr, ok := err.(frostedmd.CmdError); assert.True(ok,
+				"CmdError for %+v", tc.opts) {
+				assert.Equal(tc.code, cmdErr.Code, "code for %+v", tc.opts)
+			}
+		}
+	}
+
+}
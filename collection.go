@@ -0,0 +1,269 @@
+// collection.go - collections of documents queried by their Meta.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CollectionExtensions are the file extensions loaded by LoadCollection.
+var CollectionExtensions = []string{".md", ".markdown"}
+
+// Collection is a set of parsed documents, as loaded from a file tree.  Its
+// Docs are sorted by path unless otherwise specified.
+type Collection struct {
+	Docs   []*ParseResult
+	Errors []error // errors for files not included in Docs
+	byPath map[string]*ParseResult
+}
+
+// Predicate is a test of a document, used with Collection.Filter.
+type Predicate func(res *ParseResult) bool
+
+// Grouper returns the group names for a document, used with
+// Collection.GroupBy.  A document may be in any number of groups.
+type Grouper func(res *ParseResult) []string
+
+// NewCollection returns a Collection of the given documents, which must
+// all have their File set.
+func NewCollection(docs []*ParseResult) *Collection {
+
+	c := &Collection{
+		Docs:   docs,
+		byPath: make(map[string]*ParseResult, len(docs)),
+	}
+	for _, res := range docs {
+		c.byPath[res.File.Path] = res
+	}
+	return c
+}
+
+// LoadCollection parses every file under root in fsys having one of the
+// CollectionExtensions, using ParseFile with up to Workers goroutines.
+// Hidden files and directories, whose names begin with a dot, are skipped.
+// Documents which can not be read, or have errors in their Meta, are left
+// out of the Docs; their errors, with the file path prepended, are in
+// Errors.  The error returned is only for failure to walk the tree.
+func (p *Parser) LoadCollection(fsys fs.FS, root string) (*Collection, error) {
+
+	names := []string{}
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && hasExtension(name, CollectionExtensions) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results, errs := p.parseFiles(fsys, names)
+	docs := []*ParseResult{}
+	var fileErrs []error
+	for i, res := range results {
+		if errs[i] != nil {
+			fileErrs = append(fileErrs,
+				fmt.Errorf("%s: %w", names[i], errs[i]))
+			continue
+		}
+		docs = append(docs, res)
+	}
+	c := NewCollection(docs)
+	c.Errors = fileErrs
+	return c, nil
+}
+
+// parseFiles parses the named files in fsys with ParseFile, using up to
+// Workers goroutines, and returns the results and errors by index.
+func (p *Parser) parseFiles(fsys fs.FS, names []string) ([]*ParseResult, []error) {
+
+	results := make([]*ParseResult, len(names))
+	errs := make([]error, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < p.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = p.ParseFile(fsys, names[i])
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs
+}
+
+// hasExtension returns true if the name has one of the extensions, in any
+// case.
+func hasExtension(name string, exts []string) bool {
+
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of documents in the collection.
+func (c *Collection) Len() int {
+	return len(c.Docs)
+}
+
+// Get returns the document with the given path, or nil if there is none.
+func (c *Collection) Get(path string) *ParseResult {
+	return c.byPath[path]
+}
+
+// Filter returns a new Collection of the documents matching all the
+// predicates.
+func (c *Collection) Filter(preds ...Predicate) *Collection {
+
+	docs := []*ParseResult{}
+	for _, res := range c.Docs {
+		ok := true
+		for _, pred := range preds {
+			if !pred(res) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			docs = append(docs, res)
+		}
+	}
+	return NewCollection(docs)
+}
+
+// Sort returns a new Collection with the documents sorted by the value of
+// the Meta key, per compareValues: numbers numerically, dates
+// chronologically, and everything else as strings.  Documents without the
+// key sort last in either direction; ties are kept in their prior order.
+func (c *Collection) Sort(key string, reverse bool) *Collection {
+
+	docs := make([]*ParseResult, len(c.Docs))
+	copy(docs, c.Docs)
+	sort.SliceStable(docs, func(i, j int) bool {
+		a := metaValue(docs[i].Meta, key)
+		b := metaValue(docs[j].Meta, key)
+		if reverse && a != nil && b != nil {
+			a, b = b, a
+		}
+		return compareValues(a, b) < 0
+	})
+	return NewCollection(docs)
+}
+
+// GroupBy returns the documents split into new Collections by the group
+// names returned by the Grouper.  Documents without a group are omitted.
+func (c *Collection) GroupBy(group Grouper) map[string]*Collection {
+
+	lists := map[string][]*ParseResult{}
+	for _, res := range c.Docs {
+		for _, name := range group(res) {
+			lists[name] = append(lists[name], res)
+		}
+	}
+	groups := make(map[string]*Collection, len(lists))
+	for name, docs := range lists {
+		groups[name] = NewCollection(docs)
+	}
+	return groups
+}
+
+// HasTag returns a Predicate matching documents whose Tags contain tag.
+func HasTag(tag string) Predicate {
+	return Contains("Tags", tag)
+}
+
+// Contains returns a Predicate matching documents in which the value for
+// the Meta key is a list containing an item equal to value, or a string
+// containing value as a substring.
+func Contains(key string, value interface{}) Predicate {
+
+	return func(res *ParseResult) bool {
+		v := metaValue(res.Meta, key)
+		if s, ok := v.(string); ok {
+			return strings.Contains(s, fmt.Sprint(value))
+		}
+		for _, item := range metaList(v) {
+			if compareValues(item, value) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Equals returns a Predicate matching documents in which the value for the
+// Meta key is equal to value, per compareValues.
+func Equals(key string, value interface{}) Predicate {
+
+	return func(res *ParseResult) bool {
+		v := metaValue(res.Meta, key)
+		return v != nil && compareValues(v, value) == 0
+	}
+}
+
+// DateRange returns a Predicate matching documents in which the value for
+// the Meta key is a date no earlier than from and no later than to.  A
+// zero from or to leaves that end of the range open.
+func DateRange(key string, from, to time.Time) Predicate {
+
+	return func(res *ParseResult) bool {
+		t, ok := metaTime(metaValue(res.Meta, key))
+		if !ok {
+			return false
+		}
+		return (from.IsZero() || !t.Before(from)) &&
+			(to.IsZero() || !t.After(to))
+	}
+}
+
+// ByKey returns a Grouper by the value for the Meta key.  Documents with
+// list values are in a group for each item.
+func ByKey(key string) Grouper {
+
+	return func(res *ParseResult) []string {
+		names := []string{}
+		for _, item := range metaList(metaValue(res.Meta, key)) {
+			names = append(names, fmt.Sprint(item))
+		}
+		return names
+	}
+}
+
+// ByYear returns a Grouper by the year of the date value for the Meta key.
+func ByYear(key string) Grouper {
+
+	return func(res *ParseResult) []string {
+		t, ok := metaTime(metaValue(res.Meta, key))
+		if !ok {
+			return nil
+		}
+		return []string{strconv.Itoa(t.Year())}
+	}
+}
@@ -0,0 +1,139 @@
+// collection_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func collectionTestFS() fstest.MapFS {
+
+	return fstest.MapFS{
+		"posts/one.md": {Data: []byte(`# One
+
+    Date: 2024-01-15
+    Tags: [golang, yaml]
+    Weight: 3
+`)},
+		"posts/two.markdown": {Data: []byte(`# Two
+
+    Date: 2023-12-01
+    Tags: [rust]
+    Weight: 20
+`)},
+		"posts/three.md": {Data: []byte(`# Three
+
+    Date: 2024-03-01T10:00:00Z
+    Tags: golang
+`)},
+		"posts/broken.md": {Data: []byte("# Broken\n\n    Bad: [\n")},
+		"posts/skip.txt":  {Data: []byte("# Skipped\n")},
+	}
+}
+
+func paths(c *frostedmd.Collection) []string {
+
+	list := []string{}
+	for _, res := range c.Docs {
+		list = append(list, res.File.Path)
+	}
+	return list
+}
+
+func Test_LoadCollection(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c, err := frostedmd.New().LoadCollection(collectionTestFS(), "posts")
+
+	if assert.Nil(err, "no error returned") {
+		assert.Equal([]string{"posts/one.md", "posts/three.md",
+			"posts/two.markdown"}, paths(c), "docs loaded in path order")
+		assert.Equal(3, c.Len(), "Len as expected")
+		if assert.Equal(1, len(c.Errors), "one file error") {
+			assert.Regexp("^posts/broken.md: ", c.Errors[0].Error(),
+				"error has path")
+		}
+		if assert.NotNil(c.Get("posts/two.markdown"), "Get by path") {
+			assert.Equal("Two", c.Get("posts/two.markdown").Meta["Title"],
+				"Get returns doc")
+		}
+		assert.Nil(c.Get("posts/broken.md"), "Get nil for broken doc")
+	}
+
+	_, err = frostedmd.New().LoadCollection(collectionTestFS(), "nope")
+	assert.Error(err, "error for missing root")
+
+}
+
+func Test_Collection_Filter(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c, _ := frostedmd.New().LoadCollection(collectionTestFS(), ".")
+
+	assert.Equal([]string{"posts/one.md", "posts/three.md"},
+		paths(c.Filter(frostedmd.HasTag("golang"))), "tag filter")
+	assert.Equal([]string{"posts/two.markdown"},
+		paths(c.Filter(frostedmd.Equals("Weight", "20"))),
+		"equality filter, string for number")
+	assert.Equal([]string{"posts/one.md"},
+		paths(c.Filter(frostedmd.Contains("Title", "n"))),
+		"substring filter")
+	assert.Equal([]string{"posts/one.md", "posts/three.md"},
+		paths(c.Filter(frostedmd.DateRange("Date",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}))),
+		"open-ended date range")
+	assert.Equal([]string{"posts/one.md"},
+		paths(c.Filter(
+			frostedmd.HasTag("golang"),
+			frostedmd.DateRange("Date", time.Time{},
+				time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)),
+		)), "multiple predicates")
+
+}
+
+func Test_Collection_Sort(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c, _ := frostedmd.New().LoadCollection(collectionTestFS(), ".")
+
+	assert.Equal([]string{"posts/two.markdown", "posts/one.md",
+		"posts/three.md"}, paths(c.Sort("Date", false)), "sorted by date")
+	assert.Equal([]string{"posts/three.md", "posts/one.md",
+		"posts/two.markdown"}, paths(c.Sort("Date", true)),
+		"reverse sorted by date")
+	assert.Equal([]string{"posts/one.md", "posts/two.markdown",
+		"posts/three.md"}, paths(c.Sort("Weight", false)),
+		"sorted numerically, missing last")
+	assert.Equal([]string{"posts/two.markdown", "posts/one.md",
+		"posts/three.md"}, paths(c.Sort("Weight", true)),
+		"reverse sorted numerically, missing still last")
+
+}
+
+func Test_Collection_GroupBy(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c, _ := frostedmd.New().LoadCollection(collectionTestFS(), ".")
+
+	tags := c.GroupBy(frostedmd.ByKey("Tags"))
+	assert.Equal(3, len(tags), "three tags")
+	assert.Equal([]string{"posts/one.md", "posts/three.md"},
+		paths(tags["golang"]), "list and scalar values grouped")
+	assert.Equal([]string{"posts/one.md"}, paths(tags["yaml"]), "yaml tag")
+
+	years := c.GroupBy(frostedmd.ByYear("Date"))
+	assert.Equal(2, len(years), "two years")
+	assert.Equal([]string{"posts/one.md", "posts/three.md"},
+		paths(years["2024"]), "grouped by year")
+
+}
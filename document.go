@@ -0,0 +1,170 @@
+// document.go - full HTML5 documents.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// DefaultStyle is the name of the canned stylesheet used by RenderDocument
+// if the Parser has no Style set.
+const DefaultStyle = "default"
+
+// Styles holds the canned stylesheets available to RenderDocument, by name.
+// The "none" style results in a document without any stylesheet.
+var Styles = map[string]string{
+	"default": `body {
+  max-width: 44em;
+  margin: 2em auto;
+  padding: 0 1em;
+  font-family: Georgia, "Times New Roman", serif;
+  line-height: 1.5;
+  color: #222;
+  background: #fff;
+}
+h1, h2, h3, h4, h5, h6 {
+  font-family: "Helvetica Neue", Helvetica, Arial, sans-serif;
+  line-height: 1.2;
+}
+a { color: #1a5a96; }
+pre, code { font-family: Menlo, Consolas, monospace; font-size: 0.9em; }
+pre { padding: 0.5em; overflow: auto; background: #f5f5f5; }
+blockquote { margin-left: 0; padding-left: 1em; border-left: 3px solid #ddd; }
+table { border-collapse: collapse; }
+th, td { padding: 0.25em 0.5em; border: 1px solid #ccc; }
+img { max-width: 100%; }`,
+	"dark": `body {
+  max-width: 44em;
+  margin: 2em auto;
+  padding: 0 1em;
+  font-family: "Helvetica Neue", Helvetica, Arial, sans-serif;
+  line-height: 1.5;
+  color: #ddd;
+  background: #1e1e1e;
+}
+a { color: #7ab7ff; }
+pre, code { font-family: Menlo, Consolas, monospace; font-size: 0.9em; }
+pre { padding: 0.5em; overflow: auto; background: #2b2b2b; }
+blockquote { margin-left: 0; padding-left: 1em; border-left: 3px solid #555; }
+table { border-collapse: collapse; }
+th, td { padding: 0.25em 0.5em; border: 1px solid #555; }
+img { max-width: 100%; }`,
+	"none": "",
+}
+
+// documentTemplate is the template for full HTML5 documents.
+var documentTemplate = template.Must(template.New("document").Parse(
+	`<!DOCTYPE html>
+<html{{ with .Lang }} lang="{{ . }}"{{ end }}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+{{- with .Description }}
+<meta name="description" content="{{ . }}">
+{{- end }}
+{{- with .Keywords }}
+<meta name="keywords" content="{{ . }}">
+{{- end }}
+{{- with .StyleLink }}
+<link rel="stylesheet" href="{{ . }}">
+{{- end }}
+{{- with .Style }}
+<style>
+{{ . }}
+</style>
+{{- end }}
+</head>
+<body>
+{{ .Content }}
+</body>
+</html>
+`))
+
+// documentData is the data used with the documentTemplate.
+type documentData struct {
+	Title       string
+	Description string
+	Keywords    string
+	Lang        string
+	Style       template.CSS
+	StyleLink   string
+	Content     template.HTML
+}
+
+// RenderDocument parses the input as with Parse, and replaces the Content
+// of the result with a complete HTML5 document.
+//
+// The document's title, description, keywords and language are taken from
+// the Meta keys Title, Description, Tags and Lang respectively.  The Style
+// of the Parser determines its stylesheet: this may be the name of one of
+// the canned Styles, or the URL of a stylesheet to be linked; if it is
+// empty the DefaultStyle is used.
+//
+// As with Parse, the document is returned even if there is an error in the
+// Meta Block.
+func (p *Parser) RenderDocument(input []byte) (*ParseResult, error) {
+
+	res, err := p.Parse(input)
+	if docErr := p.wrapDocument(res); docErr != nil {
+		return res, docErr
+	}
+	return res, err
+}
+
+// wrapDocument replaces the Content of res with a complete HTML5 document.
+func (p *Parser) wrapDocument(res *ParseResult) error {
+
+	// Titles taken from the first heading are HTML, and will be escaped
+	// again by the template.
+	data := documentData{
+		Title:       html.UnescapeString(metaString(res.Meta, "Title")),
+		Description: metaString(res.Meta, "Description"),
+		Keywords:    metaString(res.Meta, "Tags"),
+		Lang:        metaString(res.Meta, "Lang"),
+		Content:     template.HTML(res.Content),
+	}
+	style := p.Style
+	if style == "" {
+		style = DefaultStyle
+	}
+	if css, ok := Styles[style]; ok {
+		data.Style = template.CSS(css)
+	} else {
+		data.StyleLink = style
+	}
+
+	var buf bytes.Buffer
+	if err := documentTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+	res.Content = buf.Bytes()
+	return nil
+}
+
+// metaString returns a string representation of the meta value for key,
+// which may also be given in all-lowercase or all-uppercase.  Lists are
+// joined with commas.  If there is no such value, the empty string is
+// returned.
+func metaString(mm map[string]interface{}, key string) string {
+
+	switch v := metaValue(mm, key).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
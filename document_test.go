@@ -0,0 +1,91 @@
+// document_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_RenderDocument(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# Doc & Title
+
+    Description: A "fine" doc.
+    Tags: [fee, fi]
+    Lang: de
+
+Hello.
+`
+	exp := `<!DOCTYPE html>
+<html lang="de">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Doc &amp; Title</title>
+<meta name="description" content="A &#34;fine&#34; doc.">
+<meta name="keywords" content="fee, fi">
+<link rel="stylesheet" href="/css/site.css">
+</head>
+<body>
+<h1>Doc &amp; Title</h1>
+
+<p>Hello.</p>
+
+</body>
+</html>
+`
+
+	parser := frostedmd.New()
+	parser.Style = "/css/site.css"
+	res, err := parser.RenderDocument([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal("de", res.Meta["Lang"], "meta intact")
+	assert.Equal(exp, string(res.Content), "document as expected")
+
+}
+
+func Test_RenderDocument_Styles(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := []byte("Hello.")
+
+	res, err := frostedmd.New().RenderDocument(input)
+	assert.Nil(err, "no error returned")
+	assert.Contains(string(res.Content),
+		"<style>\n"+frostedmd.Styles[frostedmd.DefaultStyle]+"\n</style>",
+		"default style included")
+	assert.Contains(string(res.Content), "<html>\n", "no lang")
+	assert.Contains(string(res.Content), "<title></title>", "empty title")
+
+	parser := frostedmd.New()
+	parser.Style = "none"
+	res, err = parser.RenderDocument(input)
+	assert.Nil(err, "no error returned")
+	assert.NotContains(string(res.Content), "<style>", "no style")
+	assert.NotContains(string(res.Content), "<link", "no link")
+
+}
+
+func Test_RenderDocument_MetaError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Here\n\n```yaml\nfoo: [1,true,3\n```\n\nThere."
+
+	res, err := frostedmd.New().RenderDocument([]byte(input))
+
+	assert.Error(err, "error returned")
+	assert.Contains(string(res.Content), "<title></title>",
+		"document rendered without meta")
+	assert.Contains(string(res.Content), "<p>There.</p>",
+		"document rendered with content")
+
+}
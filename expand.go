@@ -0,0 +1,195 @@
+// expand.go - template expansion within Markdown sources.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateError describes an error encountered while expanding templates
+// in a Markdown source, with the line number on which it occurred.
+type TemplateError struct {
+	Line int
+	Err  error
+}
+
+var templateErrorRegexp = regexp.MustCompile(`^template: [^:]*:(\d+):(\d+:)? `)
+
+// newTemplateError returns a TemplateError for the text/template error err,
+// which carries the line number in its message.
+func newTemplateError(err error) TemplateError {
+
+	m := templateErrorRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return TemplateError{Err: err}
+	}
+	line, _ := strconv.Atoi(m[1])
+	return TemplateError{Line: line, Err: err}
+}
+
+// Error stringifies the error per the error interface.
+func (e TemplateError) Error() string {
+
+	msg := templateErrorRegexp.ReplaceAllString(e.Err.Error(), "")
+	if e.Line == 0 {
+		return "Template error: " + msg
+	}
+	return fmt.Sprintf("Template error on line %d: %s", e.Line, msg)
+}
+
+// Unwrap returns the underlying text/template error.
+func (e TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// parseExpanded parses the input after expanding it as a text/template.
+//
+// In order to have the Meta available to the template, the input is first
+// parsed as-is.  The template data is then a TemplateData without Content.
+// The Meta Block is expanded along with everything else, thus its values
+// may refer to each other; template actions that are not valid YAML or JSON
+// as such, e.g. "Title: {{ .Meta.Name }}", are blanked out for the first
+// parse.  Code blocks and code spans, other than the Meta Block, and any
+// shortcode tags left in the source are not expanded.
+//
+// If the first parse or the template expansion fails, the result of the
+// first parse is returned together with the error.  Error lines are those
+// of the input, not of the template output.
+func (p *Parser) parseExpanded(input []byte) (*ParseResult, error) {
+
+	res, err := p.render(input)
+	if _, ok := err.(MetaError); ok {
+		if blanked := blankMetaActions(input, p.MetaAtEnd); blanked != nil {
+			if bres, berr := p.render(blanked); berr == nil {
+				res, err = bres, nil
+			}
+		}
+	}
+	if err != nil {
+		return res, err
+	}
+
+	src, restore := protectTemplateSource(input, p.MetaAtEnd)
+	tmpl, err := template.New("markdown").Funcs(p.TemplateFuncs).Parse(src)
+	if err != nil {
+		return res, newTemplateError(err)
+	}
+	data := NewTemplateData(res)
+	data.Content = ""
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return res, newTemplateError(err)
+	}
+
+	// Meta errors are reported on the lines of the unexpanded Meta Block.
+	output := restore(buf.Bytes())
+	res, err = p.render(output)
+	if me, ok := err.(MetaError); ok && me.Line > 0 {
+		ms := locateMeta(input, p.MetaAtEnd)
+		if out := locateMeta(output, p.MetaAtEnd); ms != nil && out != nil {
+			me.Line += ms.line - out.line
+			err = me
+		}
+	}
+	return res, err
+}
+
+var templateActionRegexp = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+
+// blankMetaActions returns the input with the template actions in its Meta
+// Block removed, or nil if there are none.
+func blankMetaActions(input []byte, atEnd bool) []byte {
+
+	ms := locateMeta(input, atEnd)
+	if ms == nil {
+		return nil
+	}
+	block := input[ms.start:ms.end]
+	if !templateActionRegexp.Match(block) {
+		return nil
+	}
+	out := append([]byte{}, input[:ms.start]...)
+	out = append(out, templateActionRegexp.ReplaceAll(block, nil)...)
+	return append(out, input[ms.end:]...)
+}
+
+// protectTemplateSource returns the input as a template source in which
+// code, other than the Meta Block, and shortcode tags are replaced by
+// alphanumeric tokens, followed by as many newlines as they contain so that
+// line numbers are kept; and a function restoring them in the output.
+func protectTemplateSource(input []byte, atEnd bool) (string, func([]byte) []byte) {
+
+	ranges := textRanges(input)
+	if ms := locateMeta(input, atEnd); ms != nil {
+		ranges = append(ranges, [2]int{ms.start, ms.end})
+		sort.Slice(ranges, func(i, j int) bool {
+			return ranges[i][0] < ranges[j][0]
+		})
+	}
+
+	prefix := "fmdtpl"
+	for bytes.Contains(input, []byte(prefix)) {
+		prefix += "z"
+	}
+	var src strings.Builder
+	chunks := [][]byte{}
+	protect := func(chunk []byte) {
+		if len(chunk) == 0 {
+			return
+		}
+		fmt.Fprintf(&src, "%s%dx", prefix, len(chunks))
+		src.WriteString(strings.Repeat("\n", bytes.Count(chunk, []byte("\n"))))
+		chunks = append(chunks, chunk)
+	}
+
+	pos := 0
+	for _, r := range ranges {
+		protect(input[pos:r[0]])
+		pos = r[0]
+		for pos < r[1] {
+			idx := bytes.Index(input[pos:r[1]], []byte("{{<"))
+			if idx < 0 {
+				break
+			}
+			tag := scanShortcodeTag(input[:r[1]], pos+idx)
+			if tag == nil {
+				src.Write(input[pos : pos+idx+3])
+				pos += idx + 3
+				continue
+			}
+			src.Write(input[pos:tag.start])
+			protect(input[tag.start:tag.end])
+			pos = tag.end
+		}
+		src.Write(input[pos:r[1]])
+		pos = r[1]
+	}
+	protect(input[pos:])
+
+	// The newlines after a token may have been trimmed by the template.
+	re := regexp.MustCompile(prefix + `(\d+)x\n*`)
+	restore := func(output []byte) []byte {
+		return re.ReplaceAllFunc(output, func(m []byte) []byte {
+			token := bytes.TrimRight(m, "\n")
+			idx, _ := strconv.Atoi(string(token[len(prefix) : len(token)-1]))
+			if idx >= len(chunks) {
+				return m
+			}
+			chunk := chunks[idx]
+			extra := len(m) - len(token) - bytes.Count(chunk, []byte("\n"))
+			if extra < 0 {
+				extra = 0
+			}
+			return append(append([]byte{}, chunk...),
+				bytes.Repeat([]byte("\n"), extra)...)
+		})
+	}
+	return src.String(), restore
+}
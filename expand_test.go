@@ -0,0 +1,156 @@
+// expand_test.go
+
+package frostedmd_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Parse_ExpandTemplates(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# Release Notes
+
+    Version: 1.2.3
+    Date: 2024-01-02
+    Description: Notes for {{ .Meta.Version }}.
+
+Version {{ .Meta.Version }} released on {{ .Meta.Date }}
+by {{ shout "the team" }}.
+
+{{ range .Headings }}{{ .Text }}{{ end }}
+`
+	expMap := map[string]interface{}{
+		"Title":       "Release Notes",
+		"Version":     "1.2.3",
+		"Date":        "2024-01-02",
+		"Description": "Notes for 1.2.3.",
+	}
+	expContent := `<h1>Release Notes</h1>
+
+<p>Version 1.2.3 released on 2024-01-02
+by THE TEAM.</p>
+
+<p>Release Notes</p>
+`
+
+	parser := frostedmd.New()
+	parser.ExpandTemplates = true
+	parser.TemplateFuncs = template.FuncMap{"shout": strings.ToUpper}
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(expMap, res.Meta, "meta map as expected")
+	assert.Equal(expContent, string(res.Content), "content as expected")
+
+}
+
+func Test_Parse_ExpandTemplates_Off(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "    Version: 1\n\nVersion {{ .Meta.Version }}."
+
+	res, err := frostedmd.New().Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal("<p>Version {{ .Meta.Version }}.</p>\n",
+		string(res.Content), "no expansion by default")
+
+}
+
+func Test_Parse_ExpandTemplates_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cases := [][3]string{
+		{"    Version: 1\n\nOne.\n\nTwo {{ nope }}.\n",
+			"Template error on line 5: function \"nope\" not defined",
+			"<p>Two {{ nope }}.</p>"},
+		{"    Version: 1\n\nOne.\n\nTwo {{ .Meta.Version.Foo }}.\n",
+			"Template error on line 5: executing \"markdown\" at " +
+				"<.Meta.Version.Foo>: can't evaluate field Foo in type " +
+				"interface {}",
+			"<p>Two {{ .Meta.Version.Foo }}.</p>"},
+	}
+
+	parser := frostedmd.New()
+	parser.ExpandTemplates = true
+	for _, c := range cases {
+		input, expErr, expContent := c[0], c[1], c[2]
+		res, err := parser.Parse([]byte(input))
+		if assert.Error(err, "error returned") {
+			assert.Equal(expErr, err.Error(), "error useful")
+			var te frostedmd.TemplateError
+			if assert.True(errors.As(err, &te), "TemplateError") {
+				assert.Equal(5, te.Line, "line number set")
+			}
+		}
+		assert.Equal(map[string]interface{}{"Version": 1}, res.Meta,
+			"meta from unexpanded input")
+		assert.Contains(string(res.Content), expContent,
+			"content unexpanded")
+	}
+
+	// Meta errors are reported as such, with no expansion.
+	res, err := parser.Parse([]byte("```yaml\nfoo: [\n```\n\n{{ nope }}"))
+	if assert.Error(err, "error returned") {
+		assert.Regexp("^yaml", err.Error(), "meta error returned")
+	}
+	assert.Equal("<p>{{ nope }}</p>\n", string(res.Content),
+		"content unexpanded")
+
+}
+
+func Test_Parse_ExpandTemplates_Code(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "    Name: fmd\n    Title: {{ .Meta.Name }} docs\n\n" +
+		"Use `{{ .Meta.Name }}` for {{ .Meta.Name }}.\n\n" +
+		"```\n{{ .Meta.Nope.Foo }}\n```\n\n" +
+		"{{< unknown >}} on line {{ 10 }}\n"
+	exp := "<p>Use <code>{{ .Meta.Name }}</code> for fmd.</p>\n\n" +
+		"<pre><code>{{ .Meta.Nope.Foo }}\n</code></pre>\n\n" +
+		"<p>{{&lt; unknown &gt;}} on line 10</p>\n"
+
+	parser := frostedmd.New()
+	parser.ExpandTemplates = true
+	res, err := parser.Parse([]byte(input))
+	if assert.Nil(err, "no error returned") {
+		assert.Equal("fmd docs", res.Meta["Title"], "meta expanded")
+		assert.Equal(exp, string(res.Content), "code not expanded")
+	}
+
+	_, err = parser.Parse([]byte(input + "\n{{ nope }}\n"))
+	var te frostedmd.TemplateError
+	if assert.True(errors.As(err, &te), "TemplateError") {
+		assert.Equal(12, te.Line, "line counts protected code")
+	}
+}
+
+func Test_Parse_ExpandTemplates_ErrorLines(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Lines\n\n{{< note >}}\nOne.\n\nTwo.\n{{< /note >}}\n\n" +
+		"{{ nope }}\n"
+	parser := frostedmd.New()
+	parser.ExpandTemplates = true
+	parser.Shortcodes = testShortcodes()
+
+	_, err := parser.Parse([]byte(input))
+	var te frostedmd.TemplateError
+	if assert.True(errors.As(err, &te), "TemplateError returned") {
+		assert.Equal(9, te.Line, "error on line of original input")
+	}
+
+}
@@ -0,0 +1,100 @@
+// file.go - parsing files from a filesystem.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ParseFile reads the named file from fsys and parses it, setting the File
+// in the result and adding file-derived keys to the Meta: Path, Slug and
+// ModTime.  If the Parser's FileMetaKey is set, the keys are added in a map
+// under that key; otherwise they are added at the top level.  Existing keys
+// are never replaced, thus a document may set its own Slug, or its own value
+// for the FileMetaKey.
+//
+// Includes are only resolved if the Parser's FS is set, which may well be
+// the same as fsys.
+//
+// As with Parse, the result is returned even if there is an error in the
+// Meta Block; but not if the file can not be read.
+func (p *Parser) ParseFile(fsys fs.FS, name string) (*ParseResult, error) {
+
+	input, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseFileInput(input, name, info)
+}
+
+// parseFileInput parses the input read from the named file, as ParseFile
+// does after reading it.
+func (p *Parser) parseFileInput(input []byte, name string, info fs.FileInfo) (*ParseResult, error) {
+
+	res, err := p.Parse(input)
+	res.File = NewFileInfo(name, info)
+	if res.Meta != nil {
+		p.addFileMeta(res)
+	}
+	return res, err
+}
+
+// ParseGlob parses every file in fsys matching the pattern, per fs.Glob,
+// in lexical order.  All results are returned, even if some files have
+// errors; the first error is returned with the file name prepended.
+func (p *Parser) ParseGlob(fsys fs.FS, pattern string) ([]*ParseResult, error) {
+
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*ParseResult{}
+	var firstErr error
+	for _, name := range names {
+		res, err := p.ParseFile(fsys, name)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", name, err)
+		}
+		if res != nil {
+			results = append(results, res)
+		}
+	}
+	return results, firstErr
+}
+
+// addFileMeta adds the file-derived keys to the result's Meta.
+func (p *Parser) addFileMeta(res *ParseResult) {
+
+	fm := map[string]interface{}{
+		"Path":    res.File.Path,
+		"Slug":    PathSlug(res.File.Path),
+		"ModTime": res.File.ModTime,
+	}
+	if p.FileMetaKey != "" {
+		fm = map[string]interface{}{p.FileMetaKey: fm}
+	}
+	mergeMeta(res.Meta, []map[string]interface{}{fm})
+}
+
+// PathSlug returns a slug for a file path: the path without its extension,
+// with every element converted by Slugify.  For example, the slug for
+// "Guides/Deploy Guide.md" is "guides/deploy-guide".
+func PathSlug(name string) string {
+
+	name = strings.TrimSuffix(name, path.Ext(name))
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = Slugify(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,142 @@
+// file_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func fileTestFS() fstest.MapFS {
+
+	mod := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	return fstest.MapFS{
+		"docs/Deploy Guide.md": {
+			Data:    []byte("# Deploy\n\n    Slug: custom\n\nDeploy it.\n"),
+			ModTime: mod,
+		},
+		"docs/intro.md": {
+			Data: []byte("# Intro\n\n{{< include \"partials/note.md\" >}}\n"),
+		},
+		"docs/broken.md": {
+			Data: []byte("# Broken\n\n    Bad: [\n"),
+		},
+		"partials/note.md": {Data: []byte("A note.\n")},
+	}
+}
+
+func Test_ParseFile(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res, err := frostedmd.New().ParseFile(fileTestFS(), "docs/Deploy Guide.md")
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(map[string]interface{}{
+		"Title":   "Deploy",
+		"Slug":    "custom",
+		"Path":    "docs/Deploy Guide.md",
+		"ModTime": time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC),
+	}, res.Meta, "file meta added without replacing keys")
+	if assert.NotNil(res.File, "File set") {
+		assert.Equal("Deploy Guide.md", res.File.Name, "file name")
+		assert.Equal(int64(39), res.File.Size, "file size")
+	}
+
+}
+
+func Test_ParseFile_FileMetaKey(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	parser.FileMetaKey = "File"
+	parser.FS = fileTestFS()
+	res, err := parser.ParseFile(parser.FS, "docs/intro.md")
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(map[string]interface{}{
+		"Title": "Intro",
+		"File": map[string]interface{}{
+			"Path":    "docs/intro.md",
+			"Slug":    "docs/intro",
+			"ModTime": time.Time{},
+		},
+	}, res.Meta, "file meta added under key")
+	assert.Equal("<h1>Intro</h1>\n\n<p>A note.</p>\n", string(res.Content),
+		"includes resolved against the file system")
+
+	// Existing keys are kept, as at the top level.
+	parser.FileMetaKey = "Slug"
+	res, err = parser.ParseFile(fileTestFS(), "docs/Deploy Guide.md")
+	if assert.Nil(err, "no error returned") {
+		assert.Equal("custom", res.Meta["Slug"], "existing key kept")
+	}
+
+}
+
+func Test_ParseFile_NoFS(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res, err := frostedmd.New().ParseFile(fileTestFS(), "docs/intro.md")
+
+	assert.Nil(err, "no error returned")
+	assert.Contains(string(res.Content), "{{&lt; include",
+		"includes not resolved without FS")
+	assert.Nil(res.Includes, "no includes listed")
+
+}
+
+func Test_ParseFile_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+
+	res, err := parser.ParseFile(fileTestFS(), "docs/nope.md")
+	assert.Error(err, "error for missing file")
+	assert.Nil(res, "no result for missing file")
+
+	res, err = parser.ParseFile(fileTestFS(), "docs/broken.md")
+	assert.Error(err, "error for broken meta")
+	if assert.NotNil(res, "result for broken meta") {
+		assert.Equal("docs/broken.md", res.File.Path, "File set")
+	}
+
+}
+
+func Test_ParseGlob(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res, err := frostedmd.New().ParseGlob(fileTestFS(), "docs/*.md")
+
+	if assert.Error(err, "error returned") {
+		assert.Regexp("^docs/broken.md: ", err.Error(), "error has file name")
+	}
+	if assert.Equal(3, len(res), "all results returned") {
+		assert.Equal("docs/Deploy Guide.md", res[0].File.Path, "sorted 1")
+		assert.Equal("docs/broken.md", res[1].File.Path, "sorted 2")
+		assert.Equal("docs/intro.md", res[2].File.Path, "sorted 3")
+	}
+
+	_, err = frostedmd.New().ParseGlob(fileTestFS(), "[")
+	assert.Error(err, "error for bad pattern")
+
+}
+
+func Test_PathSlug(t *testing.T) {
+
+	assert := assert.New(t)
+
+	assert.Equal("guides/deploy-guide", frostedmd.PathSlug(
+		"Guides/Deploy Guide.md"), "slug as expected")
+	assert.Equal("readme", frostedmd.PathSlug("README"), "no extension")
+
+}
@@ -34,12 +34,31 @@
 //
 // If an appropriate meta block is found it will be excluded from the rendered
 // HTML content.
+//
+// Additional datasets may be included as named data blocks: fenced code
+// blocks whose info string contains a "data:NAME" word, for instance:
+//
+//  ```yaml data:endpoints
+//  - /api/v1/foo
+//  - /api/v1/bar
+//  ```
+//
+// Each such block is decoded like the Meta Block, stored in the Meta under
+// its NAME, and excluded from the rendered HTML content.  Data blocks may
+// appear anywhere in the document, and do not count as blocks for purposes
+// of locating the Meta Block.
 package frostedmd
 
 import (
 	// Standard Library:
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"text/template"
+	"time"
 
 	// Third-Party:
 	"gopkg.in/russross/blackfriday.v1"
@@ -75,10 +94,57 @@ const BlackFridayCommonHTMLFlags = 0 |
 
 // Parser defines a parser-renderer used for converting source data to HTML
 // and metadata.
+//
+// A Parser is safe for concurrent use as long as its fields are not changed
+// while parsing; its settings are read only from its fields, never from
+// package globals such as MetaBlockAtEnd, which only sets defaults in New.
+// Anything it calls out to, such as an FS, Resolver or Shortcode, must also
+// be safe for concurrent use.
 type Parser struct {
 	MetaAtEnd          bool
-	MarkdownExtensions int // uses blackfriday EXTENSION_* constants
-	HTMLFlags          int // uses blackfridy HTML_* constants
+	MarkdownExtensions int  // uses blackfriday EXTENSION_* constants
+	HTMLFlags          int  // uses blackfridy HTML_* constants
+	ExtractTables      bool // collect tables as data in the ParseResult
+	SectionLevel       int  // split into Sections at this heading level
+
+	// Splitter is used by ParseMulti to separate documents.
+	Splitter Splitter
+
+	// Style is the stylesheet used by RenderDocument.
+	Style string
+
+	// If ExpandTemplates is set, the input is run through text/template
+	// before rendering, with TemplateFuncs available.  Cf. expand.go.
+	ExpandTemplates bool
+	TemplateFuncs   template.FuncMap
+
+	// If FS is set, include directives are resolved against it, nesting
+	// up to MaxIncludeDepth levels.  Paths are always relative to the root
+	// of the FS, also in included files.  Cf. include.go.
+	FS              fs.FS
+	IncludeMeta     IncludeMetaPolicy
+	MaxIncludeDepth int
+
+	// Shortcodes are expanded by name, e.g. {{< youtube abc123 >}}, after
+	// includes but before templates.  Cf. shortcode.go.
+	Shortcodes map[string]Shortcode
+
+	// If WikiLinks is set, [[Page Name]] links are resolved by Resolver,
+	// or by a SlugResolver if it is nil.  Cf. wiki.go.
+	WikiLinks bool
+	Resolver  Resolver
+
+	// FileMetaKey is the Meta key under which ParseFile adds file-derived
+	// data.  If empty, the data is added at the top level.  Cf. file.go.
+	FileMetaKey string
+
+	// Workers limits the goroutines used by ParseAll and its relatives; if
+	// zero, the number of CPUs is used.  Cf. batch.go.
+	Workers int
+
+	// If Cache is set, successful results are stored in it and unchanged
+	// inputs are not parsed again.  Cf. cache.go.
+	Cache *Cache
 }
 
 // New returns a new Parser with the common flags and extensions enabled.
@@ -95,41 +161,172 @@ func NewBasic() *Parser {
 	return &Parser{}
 }
 
-// ParseResult defines the result of a Parse operation.
+// ParseResult defines the result of a Parse operation.  Warnings describe
+// problems that did not prevent parsing, such as unresolved wiki links.
+// Headings and File are provided for use in templates and the like, and
+// Includes lists the files included by the document, for tracking
+// dependencies; these are not serialized.
 type ParseResult struct {
-	Meta    map[string]interface{} `json:"meta"`
-	Content []byte                 `json:"content"`
+	Meta     map[string]interface{} `json:"meta"`
+	Content  []byte                 `json:"content"`
+	Tables   []*Table               `json:"tables,omitempty"`
+	Sections []*Section             `json:"sections,omitempty"`
+	Warnings []string               `json:"warnings,omitempty"`
+	Headings []Heading              `json:"-"`
+	File     *FileInfo              `json:"-"`
+	Includes []string               `json:"-"`
+}
+
+// FileInfo describes the file from which a ParseResult was parsed, if any.
+type FileInfo struct {
+	Path    string
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// NewFileInfo returns a FileInfo for the given path and os.FileInfo.
+func NewFileInfo(path string, info os.FileInfo) *FileInfo {
+	return &FileInfo{
+		Path:    path,
+		Name:    info.Name(),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
 }
 
 // Parse converts Markdown input into a meta map and HTML content fragment.
 // If an error is encountered while parsing the meta block, the rendered
 // content is still returned. Thus the caller may choose to handle meta
 // errors without interrupting flow.
+//
+// If include directives can not be resolved, the input is parsed without
+// them and returned together with the error.  Shortcodes that fail are
+// left as-is, with their errors returned after any meta error.
+//
+// If the Parser has a Cache, a cached result is returned if available, and
+// a successful result is stored in it otherwise.
 func (p *Parser) Parse(input []byte) (*ParseResult, error) {
 
+	if p.Cache == nil {
+		res, _, err := p.parseDeps(input)
+		return res, err
+	}
+	key := p.cacheKey(input)
+	if res, ok := p.Cache.get(key, p.FS); ok {
+		return res, nil
+	}
+	res, deps, err := p.parseDeps(input)
+	if err == nil {
+		p.Cache.put(key, res, deps)
+	}
+	return res, err
+}
+
+// parseDeps parses the input, also returning the hashes of any included
+// files by name.
+func (p *Parser) parseDeps(input []byte) (*ParseResult, map[string]string, error) {
+
+	// Error lines are mapped back to the input through the expansions.
+	sm := newSourceMap(input)
+	var included []map[string]interface{}
+	var deps map[string]string
+	if p.FS != nil {
+		expanded, inc, err := p.expandIncludes(input)
+		if err != nil {
+			res, _ := p.render(input)
+			return res, nil, err
+		}
+		input = sm.push(expanded)
+		included = inc.meta
+		deps = inc.deps
+	}
+
+	ph := newPlaceholders(input)
+	var warnings []string
+	if p.WikiLinks {
+		var expanded *sourceBuffer
+		expanded, warnings = p.expandWikiLinks(input, ph, sm)
+		input = sm.push(expanded)
+	}
+	var shortcodeErr error
+	if len(p.Shortcodes) > 0 {
+		var expanded *sourceBuffer
+		expanded, shortcodeErr = p.expandShortcodes(input, ph, sm)
+		input = sm.push(expanded)
+	}
+
+	var res *ParseResult
+	var err error
+	if p.ExpandTemplates {
+		res, err = p.parseExpanded(input)
+	} else {
+		res, err = p.render(input)
+	}
+	ph.apply(res)
+	res.Warnings = warnings
+	for name := range deps {
+		res.Includes = append(res.Includes, name)
+	}
+	sort.Strings(res.Includes)
+	if err != nil {
+		return res, deps, sm.mapError(err)
+	}
+	mergeMeta(res.Meta, included)
+	return res, deps, shortcodeErr
+}
+
+// render does the actual work of Parse, without any preprocessing.
+func (p *Parser) render(input []byte) (*ParseResult, error) {
+
 	// cf. renderer.go for the fmdRenderer definition
 	renderer := &fmdRenderer{
 		bfRenderer: blackfriday.HtmlRenderer(p.HTMLFlags,
 			"", // no title
 			"", // no css
 		),
-		metaAtEnd: p.MetaAtEnd,
+		metaAtEnd:     p.MetaAtEnd,
+		extractTables: p.ExtractTables,
+		sectionLevel:  p.SectionLevel,
 	}
 
 	htmlBytes := blackfriday.MarkdownOptions(input, renderer,
 		blackfriday.Options{Extensions: p.MarkdownExtensions})
 
 	// Partial results are useful sometimes.
-	res := &ParseResult{Content: htmlBytes}
+	res := &ParseResult{
+		Content:  htmlBytes,
+		Tables:   renderer.tables,
+		Headings: renderer.headings,
+	}
+	if p.SectionLevel > 0 {
+		res.Sections = buildSections(htmlBytes, renderer.sectionMarks)
+	}
 
 	mm, err := p.parseMeta(renderer.metaBytes, renderer.metaLang)
 	if err != nil {
-		return res, err
+		return res, newMetaError(input, p.MetaAtEnd, err)
 	}
+
+	// Named data blocks are merged in after the Meta Block, but may not
+	// replace anything in it.
+	for _, db := range renderer.dataBlocks {
+		if _, exists := mm[db.name]; exists {
+			return res, errors.New("Duplicate key for data block: " + db.name)
+		}
+		var data interface{}
+		err := decodeBlock(db.text, db.lang, &data, "data block")
+		if err != nil {
+			return res, fmt.Errorf("Error in data block %s: %s", db.name, err)
+		}
+		mm[db.name] = data
+	}
+
 	if mm["Title"] == nil && mm["TITLE"] == nil && mm["title"] == nil &&
 		renderer.headerTitle != "" {
 		mm["Title"] = renderer.headerTitle
 	}
+
 	res.Meta = mm
 	return res, nil
 }
@@ -140,35 +337,36 @@ func (p *Parser) parseMeta(input []byte, lang string) (map[string]interface{}, e
 	if len(input) == 0 {
 		return mm, nil
 	}
+	err := decodeBlock(input, lang, &mm, "meta block")
+	return mm, err
+
+}
+
+// decodeBlock decodes the input into v according to lang, which is expected
+// to be the info string of a code block.  The kind of block being decoded
+// is used in error messages.
+func decodeBlock(input []byte, lang string, v interface{}, kind string) error {
 
 	// Only JSON and YAML are supported for undefined-language code blocks,
 	// though we should keep in mind that it's possible the Markdown parser
 	// might try at some point to guess.
 	if lang == "" {
 		// We expect the JSON decoder to bail out fast on bad formats, so:
-		if err := json.Unmarshal(input, &mm); err == nil {
-			return mm, nil
+		if err := json.Unmarshal(input, v); err == nil {
+			return nil
 		}
 		lang = "yaml"
 	}
 
 	switch lang {
 	case "json":
-		err := json.Unmarshal(input, &mm)
-		if err != nil {
-			return mm, err
-		}
+		return json.Unmarshal(input, v)
 	case "yaml":
-		err := yaml.Unmarshal(input, &mm)
-		if err != nil {
-			return mm, err
-		}
+		return yaml.Unmarshal(input, v)
 	default:
-		return mm, errors.New("Unsupported language for meta block: " + lang)
+		return errors.New("Unsupported language for " + kind + ": " + lang)
 	}
 
-	return mm, nil
-
 }
 
 // MarkdownBasic converts Markdown input using the same options as
@@ -3,6 +3,7 @@
 package frostedmd_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/biztos/frostedmd"
@@ -157,6 +158,34 @@ func Test_Parse_Error_YAML(t *testing.T) {
 	assert.Equal(expContent, string(res.Content), "content as expected")
 }
 
+func Test_Parse_Error_MetaLine(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		input string
+		atEnd bool
+		line  int
+	}{
+		{"# Here\n\n```json\n{ foo: \"bar }\n```\n\nThere.", false, 4},
+		{"```json\n{\n  \"a\": 1,\n  b\n}\n```\n", false, 4},
+		{"```json\n{\"a\": 1,\n\"b\" 2}\n```\n", false, 3},
+		{"# Here\n\n```yaml\nok: 1\nfoo: [1,true,3\n```\n", false, 5},
+		{"    Title: x\n    Tags: [a, b\n\nText.\n", false, 2},
+		{"Text.\n\n    a: 1\n    b: c: d\n", true, 4},
+		{"# Here\n\n```ruby\nfoo\n```\n", false, 4},
+	} {
+		parser := frostedmd.New()
+		parser.MetaAtEnd = tc.atEnd
+		_, err := parser.Parse([]byte(tc.input))
+		var me frostedmd.MetaError
+		if assert.True(errors.As(err, &me), "MetaError for %q", tc.input) {
+			assert.Equal(tc.line, me.Line, "line for %q", tc.input)
+			assert.Equal(me.Err.Error(), me.Error(), "message unchanged")
+		}
+	}
+}
+
 func Test_Parse_LateMetaIgnored(t *testing.T) {
 
 	assert := assert.New(t)
@@ -361,3 +390,83 @@ OldSchool: &quot;YAML&quot;
 	assert.Equal(expContent, string(res.Content), "content as expected")
 
 }
+
+func Test_Parse_DataBlocks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Ima Title\n\n```yaml\nOldSchool: \"YAML\"\n```\n\n" +
+		"Endpoints:\n\n```yaml data:endpoints\n- /foo\n- /bar\n```\n\n" +
+		"Limits:\n\n```data:limits\n{\"max\": 12}\n```\n\nDone."
+
+	expMap := map[string]interface{}{
+		"Title":     "Ima Title",
+		"OldSchool": "YAML",
+		"endpoints": []interface{}{"/foo", "/bar"},
+		"limits":    map[string]interface{}{"max": float64(12)},
+	}
+	expContent := `<h1>Ima Title</h1>
+
+<p>Endpoints:</p>
+
+<p>Limits:</p>
+
+<p>Done.</p>
+`
+
+	res, err := frostedmd.New().Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(expMap, res.Meta, "meta map as expected")
+	assert.Equal(expContent, string(res.Content), "content as expected")
+
+}
+
+func Test_Parse_DataBlocks_MetaAtEnd(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Ima Title\n\nHere.\n\n" +
+		"```json\n{\"OldSchool\": \"JSON\"}\n```\n\n" +
+		"```json data:numbers\n[1,2,3]\n```\n"
+
+	expMap := map[string]interface{}{
+		"Title":     "Ima Title",
+		"OldSchool": "JSON",
+		"numbers":   []interface{}{float64(1), float64(2), float64(3)},
+	}
+	expContent := "<h1>Ima Title</h1>\n\n<p>Here.</p>\n"
+
+	parser := frostedmd.New()
+	parser.MetaAtEnd = true
+
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(expMap, res.Meta, "meta map as expected")
+	assert.Equal(expContent, string(res.Content), "content as expected")
+
+}
+
+func Test_Parse_DataBlocks_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cases := [][2]string{
+		{"```yaml\nfoo: 1\n```\n\n```yaml data:foo\n2\n```\n",
+			"Duplicate key for data block: foo"},
+		{"```yaml data:foo\n[1,2\n```\n",
+			"Error in data block foo: yaml"},
+		{"```ruby data:foo\n[1,2]\n```\n",
+			"Unsupported language for data block: ruby"},
+	}
+	for _, c := range cases {
+		input, exp := c[0], c[1]
+		res, err := frostedmd.New().Parse([]byte(input))
+		if assert.Error(err, "error returned") {
+			assert.Regexp(exp, err.Error(), "error useful")
+		}
+		assert.Nil(res.Meta, "empty meta map")
+	}
+
+}
@@ -0,0 +1,261 @@
+// handler.go - serving Markdown trees with net/http.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandlerTypes are the media types a Handler can serve, in order of
+// preference when the client does not care.
+var HandlerTypes = []string{"text/html", "application/json", "text/markdown"}
+
+// HandlerOptions configures a Handler.  The zero value is usable.
+type HandlerOptions struct {
+
+	// Parser parses the pages; if nil, New is used.  Includes are only
+	// resolved if its FS is set, normally to the same FS as the Handler.
+	Parser *Parser
+
+	// Template renders the HTML pages, as with RenderTemplate.  If nil,
+	// pages are full HTML5 documents as with RenderDocument.
+	Template *template.Template
+}
+
+// handler is the http.Handler returned by Handler.
+type handler struct {
+	fsys     fs.FS
+	parser   *Parser
+	template *template.Template
+}
+
+// Handler returns an http.Handler serving the Markdown files in fsys as
+// pages at their paths without extension: "/guide/setup" is served from
+// "guide/setup.md", and "/guide/" from "guide/index.md".  Any of the
+// CollectionExtensions may be used.  Links to relative Markdown paths in
+// the pages are rewritten to match.  Hidden files are not served, nor are
+// any other files; combine the Handler with an http.FileServer for those.
+//
+// The representation is chosen by the Accept header, per HandlerTypes:
+// "text/html" gives the page, rendered through the Template if set;
+// "application/json" gives the ParseResult as JSON; and "text/markdown"
+// gives the source as-is.  If none of these is acceptable the status is
+// 406 Not Acceptable.
+//
+// Responses have a Last-Modified header from the modification time of the
+// file, or of the latest file it includes, and a strong ETag from a hash of
+// the content, and conditional and HEAD requests are handled accordingly.
+// Errors in parsing or rendering result in a 500 Internal Server Error
+// with the error message.
+func Handler(fsys fs.FS, opts HandlerOptions) http.Handler {
+
+	h := &handler{fsys: fsys, parser: opts.Parser, template: opts.Template}
+	if h.parser == nil {
+		h.parser = New()
+	}
+	return h
+}
+
+// ServeHTTP serves the request per the http.Handler interface.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+	name := h.sourceName(r.URL.Path)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Vary", "Accept")
+	mediaType := Negotiate(r.Header.Get("Accept"), HandlerTypes)
+	if mediaType == "" {
+		http.Error(w, "Not acceptable.", http.StatusNotAcceptable)
+		return
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	modTime := info.ModTime()
+
+	var body []byte
+	if mediaType == "text/markdown" {
+		body, err = fs.ReadFile(h.fsys, name)
+	} else {
+		var res *ParseResult
+		res, err = h.parser.ParseFile(h.fsys, name)
+		if err == nil {
+			modTime = h.latest(modTime, res.Includes)
+			if mediaType == "text/html" {
+				body, err = h.render(res)
+			} else {
+				body, err = json.Marshal(jsonResult(res))
+			}
+		}
+	}
+	if err != nil {
+		http.Error(w, name+": "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	http.ServeContent(w, r, name, modTime, bytes.NewReader(body))
+}
+
+// sourceName returns the Markdown file for the URL path, or the empty
+// string if there is none.
+func (h *handler) sourceName(urlPath string) string {
+
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if strings.HasSuffix(urlPath, "/") {
+		name = path.Join(name, "index")
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return ""
+		}
+	}
+	for _, ext := range CollectionExtensions {
+		info, err := fs.Stat(h.fsys, name+ext)
+		if err == nil && !info.IsDir() {
+			return name + ext
+		}
+	}
+	return ""
+}
+
+// latest returns the latest of t and the modification times of the named
+// files.
+func (h *handler) latest(t time.Time, names []string) time.Time {
+
+	for _, name := range names {
+		if info, err := fs.Stat(h.fsys, name); err == nil &&
+			info.ModTime().After(t) {
+			t = info.ModTime()
+		}
+	}
+	return t
+}
+
+// render returns the HTML page for res.
+func (h *handler) render(res *ParseResult) ([]byte, error) {
+
+	page := *res
+	page.Content = rewriteLinks(res.Content, "")
+	if h.template != nil {
+		return RenderTemplate(h.template, &page)
+	}
+	if err := h.parser.wrapDocument(&page); err != nil {
+		return nil, err
+	}
+	return page.Content, nil
+}
+
+// jsonResult returns a copy of res whose Meta can be encoded as JSON:
+// maps decoded from YAML are given string keys, at any depth.
+func jsonResult(res *ParseResult) *ParseResult {
+
+	out := *res
+	if res.Meta != nil {
+		out.Meta = jsonValue(res.Meta).(map[string]interface{})
+	}
+	return &out
+}
+
+// jsonValue returns v with all maps converted to map[string]interface{}.
+func jsonValue(v interface{}) interface{} {
+
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		return jsonValue(stringKeys(v))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = jsonValue(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// Negotiate returns the first of the offered media types with the highest
+// quality in the Accept header, or the empty string if none is acceptable.
+// An empty header accepts anything.  Parameters other than the quality are
+// ignored.
+func Negotiate(accept string, offers []string) string {
+
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality of the media type in the Accept header,
+// from the most specific matching range.
+func acceptQuality(accept, mediaType string) float64 {
+
+	q, specificity := 0.0, -1
+	major := strings.SplitN(mediaType, "/", 2)[0]
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		rng := strings.ToLower(strings.TrimSpace(params[0]))
+		spec := -1
+		switch rng {
+		case mediaType:
+			spec = 2
+		case major + "/*":
+			spec = 1
+		case "*/*":
+			spec = 0
+		}
+		if spec <= specificity {
+			continue
+		}
+		rangeQ := 1.0
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if f, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					rangeQ = f
+				}
+			}
+		}
+		q, specificity = rangeQ, spec
+	}
+	return q
+}
@@ -0,0 +1,196 @@
+// handler_test.go
+
+package frostedmd_test
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+var handlerTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func handlerFS() fstest.MapFS {
+
+	return fstest.MapFS{
+		"index.md": {Data: []byte("# Home\n\nSee [setup](guide/setup.md#top).\n"),
+			ModTime: handlerTime},
+		"guide/setup.md": {Data: []byte("# Setup\n\n    Nested: {a: {b: 1}}\n\n" +
+			"{{< include \"parts/note.md\" >}}\n"), ModTime: handlerTime},
+		"parts/note.md": {Data: []byte("A note.\n"),
+			ModTime: handlerTime.Add(time.Hour)},
+		"other.markdown": {Data: []byte("# Other\n"), ModTime: handlerTime},
+		"bad.md":         {Data: []byte("    x: [y\n"), ModTime: handlerTime},
+		".hidden.md":     {Data: []byte("# Hidden\n"), ModTime: handlerTime},
+	}
+}
+
+// handlerOptions returns HandlerOptions with a Parser resolving includes in
+// the handlerFS.
+func handlerOptions() frostedmd.HandlerOptions {
+
+	parser := frostedmd.New()
+	parser.FS = handlerFS()
+	return frostedmd.HandlerOptions{Parser: parser}
+}
+
+func handlerGet(h http.Handler, url string, header ...string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest("GET", url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_Handler_HTML(t *testing.T) {
+
+	assert := assert.New(t)
+
+	h := frostedmd.Handler(handlerFS(), handlerOptions())
+
+	rec := handlerGet(h, "/")
+	assert.Equal(200, rec.Code, "status")
+	assert.Equal("text/html; charset=utf-8", rec.Header().Get("Content-Type"),
+		"content type")
+	assert.Equal("Accept", rec.Header().Get("Vary"), "vary")
+	assert.Contains(rec.Body.String(), "<title>Home</title>", "document")
+	assert.Contains(rec.Body.String(), `href="guide/setup#top"`,
+		"link rewritten without extension")
+
+	rec = handlerGet(h, "/guide/setup")
+	assert.Equal(200, rec.Code, "status")
+	assert.Contains(rec.Body.String(), "A note.", "include expanded")
+	assert.Equal(handlerTime.Add(time.Hour).Format(http.TimeFormat),
+		rec.Header().Get("Last-Modified"), "last modified from include")
+	assert.Regexp(`^"[0-9a-f]{32}"$`, rec.Header().Get("ETag"), "etag")
+
+	rec = handlerGet(h, "/other")
+	assert.Contains(rec.Body.String(), "<title>Other</title>",
+		"other extension")
+
+	h = frostedmd.Handler(handlerFS(), frostedmd.HandlerOptions{
+		Template: template.Must(template.New("t").Parse(
+			"<main>{{ .Meta.Title }}: {{ .Meta.Path }}</main>")),
+	})
+	rec = handlerGet(h, "/other")
+	assert.Equal("<main>Other: other.markdown</main>", rec.Body.String(),
+		"template used, with file meta")
+
+}
+
+func Test_Handler_Negotiation(t *testing.T) {
+
+	assert := assert.New(t)
+
+	h := frostedmd.Handler(handlerFS(), handlerOptions())
+
+	rec := handlerGet(h, "/guide/setup", "Accept", "application/json")
+	assert.Equal("application/json; charset=utf-8",
+		rec.Header().Get("Content-Type"), "json type")
+	var res frostedmd.ParseResult
+	if assert.Nil(json.Unmarshal(rec.Body.Bytes(), &res), "valid JSON") {
+		assert.Equal("Setup", res.Meta["Title"], "title in meta")
+		assert.Equal(map[string]interface{}{
+			"a": map[string]interface{}{"b": float64(1)},
+		}, res.Meta["Nested"], "nested YAML maps converted")
+		assert.Contains(string(res.Content), "A note.", "content")
+	}
+	htmlTag := handlerGet(h, "/guide/setup").Header().Get("ETag")
+	assert.NotEqual(htmlTag, rec.Header().Get("ETag"),
+		"etag differs by representation")
+
+	rec = handlerGet(h, "/other", "Accept", "text/markdown")
+	assert.Equal("text/markdown; charset=utf-8",
+		rec.Header().Get("Content-Type"), "markdown type")
+	assert.Equal("# Other\n", rec.Body.String(), "source as-is")
+
+	rec = handlerGet(h, "/other", "Accept", "image/png")
+	assert.Equal(406, rec.Code, "not acceptable")
+
+}
+
+func Test_Handler_Conditional(t *testing.T) {
+
+	assert := assert.New(t)
+
+	h := frostedmd.Handler(handlerFS(), handlerOptions())
+	etag := handlerGet(h, "/other").Header().Get("ETag")
+
+	rec := handlerGet(h, "/other", "If-None-Match", etag)
+	assert.Equal(304, rec.Code, "not modified by etag")
+	rec = handlerGet(h, "/other", "If-None-Match", `"nope"`)
+	assert.Equal(200, rec.Code, "modified by etag")
+
+	rec = handlerGet(h, "/other", "If-Modified-Since",
+		handlerTime.Format(http.TimeFormat))
+	assert.Equal(304, rec.Code, "not modified by time")
+	rec = handlerGet(h, "/other", "If-Modified-Since",
+		handlerTime.Add(-time.Hour).Format(http.TimeFormat))
+	assert.Equal(200, rec.Code, "modified by time")
+
+	req := httptest.NewRequest("HEAD", "/other", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(200, rec.Code, "head ok")
+	assert.Equal("", rec.Body.String(), "no body for head")
+
+}
+
+func Test_Handler_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	h := frostedmd.Handler(handlerFS(), handlerOptions())
+	for _, url := range []string{"/nope", "/guide", "/.hidden", "/other.md",
+		"/guide/"} {
+		assert.Equal(404, handlerGet(h, url).Code, "not found: %s", url)
+	}
+
+	rec := handlerGet(h, "/bad")
+	assert.Equal(500, rec.Code, "server error")
+	assert.Regexp("^bad.md: yaml: ", rec.Body.String(), "error message")
+
+	req := httptest.NewRequest("POST", "/other", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(405, rec.Code, "method not allowed")
+	assert.Equal("GET, HEAD", rec.Header().Get("Allow"), "allowed methods")
+
+}
+
+func Test_Negotiate(t *testing.T) {
+
+	assert := assert.New(t)
+
+	offers := []string{"text/html", "application/json", "text/markdown"}
+	for accept, exp := range map[string]string{
+		"":                                     "text/html",
+		"*/*":                                  "text/html",
+		"application/json":                     "application/json",
+		"text/*":                               "text/html",
+		"text/markdown, text/html;q=0.9":       "text/markdown",
+		"text/*;q=0.5, application/json;q=0.8": "application/json",
+		"text/*, text/html;q=0":                "text/markdown",
+		"*/*;q=0.1, Application/JSON":          "application/json",
+		"image/png":                            "",
+		"text/html;q=0, */*;q=0":               "",
+		"text/html;level=1;q=0.4, */*;q=0.3":   "text/html",
+		"application/json; q=bogus, text/html;q=0.5": "application/json",
+	} {
+		assert.Equal(exp, frostedmd.Negotiate(accept, offers),
+			"negotiated for %q", accept)
+	}
+	assert.Equal("", frostedmd.Negotiate("", nil), "nothing offered")
+
+}
@@ -0,0 +1,194 @@
+// include.go - transclusion of other Markdown files.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxIncludeDepth is the include depth limit used if the Parser has
+// no MaxIncludeDepth defined.
+const DefaultMaxIncludeDepth = 8
+
+// IncludeMetaPolicy defines what happens to the Meta Blocks of included
+// files.
+type IncludeMetaPolicy int
+
+const (
+	// IncludeMetaStrip removes the Meta Block from included files.
+	IncludeMetaStrip IncludeMetaPolicy = iota
+
+	// IncludeMetaMerge removes the Meta Block from included files and
+	// merges its data into the Meta of the including document.  Keys
+	// already present are never replaced, thus the including document
+	// always wins, and earlier includes win over later ones.
+	IncludeMetaMerge
+)
+
+// IncludeError describes an error encountered while resolving an include
+// directive.  File is the including file, or empty for the top-level input;
+// Line is the line of the directive within it.
+type IncludeError struct {
+	File string
+	Line int
+	Err  error
+}
+
+// Error stringifies the error per the error interface.
+func (e IncludeError) Error() string {
+
+	if e.File == "" {
+		return fmt.Sprintf("Include error on line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("Include error in %s on line %d: %s",
+		e.File, e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e IncludeError) Unwrap() error {
+	return e.Err
+}
+
+// includeRegexp matches an include directive, which must be on a line by
+// itself and not indented as code.
+var includeRegexp = regexp.MustCompile(
+	`^ {0,3}\{\{<\s*include\s+"([^"]+)"\s*>\}\}\s*$`)
+
+// includer resolves include directives against a filesystem, collecting
+// the Meta of included files if it is to be merged, and the hashes of all
+// included files for the Cache.
+type includer struct {
+	fsys     fs.FS
+	atEnd    bool
+	policy   IncludeMetaPolicy
+	maxDepth int
+	parser   *Parser
+	meta     []map[string]interface{}
+	deps     map[string]string
+}
+
+// expandIncludes replaces include directives in the input with the content
+// of the files they name, resolved against the Parser's FS.  Names are
+// relative to the root of the FS, not to the including file, thus a nested
+// include reads the same as it would at the top level.  The includer
+// is returned with any Meta to be merged, in order of inclusion, and the
+// files that were included.
+func (p *Parser) expandIncludes(input []byte) (*sourceBuffer, *includer, error) {
+
+	inc := &includer{
+		fsys:     p.FS,
+		atEnd:    p.MetaAtEnd,
+		policy:   p.IncludeMeta,
+		maxDepth: p.MaxIncludeDepth,
+		parser:   p,
+		deps:     map[string]string{},
+	}
+	if inc.maxDepth <= 0 {
+		inc.maxDepth = DefaultMaxIncludeDepth
+	}
+	output, err := inc.expand(input, nil)
+	return output, inc, err
+}
+
+// expand expands the includes in input, which was itself included via the
+// files in stack.  Directives within fenced code blocks are left alone.
+// Included content is mapped to the directive in the output.
+func (inc *includer) expand(input []byte, stack []string) (*sourceBuffer, error) {
+
+	out := &sourceBuffer{}
+	fence := ""
+	pos := 0
+	for i, line := range sourceLines(input) {
+		start := pos
+		pos += len(line)
+		fence = trackFence(fence, line)
+		m := includeRegexp.FindSubmatch(line)
+		if fence != "" || m == nil {
+			out.copy(input, start, pos)
+			continue
+		}
+		content, err := inc.include(string(m[1]), stack)
+		if err != nil {
+			if _, nested := err.(IncludeError); nested {
+				return nil, err
+			}
+			ie := IncludeError{Line: i + 1, Err: err}
+			if len(stack) > 0 {
+				ie.File = stack[len(stack)-1]
+			}
+			return nil, ie
+		}
+		if !bytes.HasSuffix(content, []byte("\n")) {
+			content = append(content, '\n')
+		}
+		out.insert(content, start)
+	}
+
+	return out, nil
+}
+
+// include returns the fully expanded content of the named file, with its
+// Meta Block handled according to policy.
+func (inc *includer) include(name string, stack []string) ([]byte, error) {
+
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		return nil, errors.New("Invalid include path: " + name)
+	}
+	for i, prev := range stack {
+		if prev == name {
+			chain := append(stack[i:], name)
+			return nil, errors.New("Include cycle: " +
+				strings.Join(chain, " -> "))
+		}
+	}
+	if len(stack) >= inc.maxDepth {
+		return nil, fmt.Errorf("Include depth limit (%d) exceeded: %s",
+			inc.maxDepth, name)
+	}
+
+	content, err := fs.ReadFile(inc.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	inc.deps[name] = hashBytes(content)
+
+	if ms := locateMeta(content, inc.atEnd); ms != nil {
+		if inc.policy == IncludeMetaMerge {
+			mm, err := inc.parser.parseMeta(ms.text, ms.lang)
+			if err != nil {
+				return nil, fmt.Errorf("Error in meta block of %s: %s",
+					name, err)
+			}
+			inc.meta = append(inc.meta, mm)
+		}
+		stripped := append([]byte{}, content[:ms.start]...)
+		content = append(stripped, content[ms.end:]...)
+	}
+
+	out, err := inc.expand(content, append(stack[:len(stack):len(stack)], name))
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// mergeMeta merges the maps in extra into mm, without replacing any keys
+// already present.
+func mergeMeta(mm map[string]interface{}, extra []map[string]interface{}) {
+
+	for _, em := range extra {
+		for k, v := range em {
+			if _, exists := mm[k]; !exists {
+				mm[k] = v
+			}
+		}
+	}
+}
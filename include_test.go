@@ -0,0 +1,268 @@
+// include_test.go
+
+package frostedmd_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/russross/blackfriday.v1"
+
+	"github.com/biztos/frostedmd"
+)
+
+func includeTestFS() fstest.MapFS {
+
+	return fstest.MapFS{
+		"partials/warning.md": {Data: []byte(`## Warning
+
+    Severity: high
+    Title: Not The Title
+
+Be careful out there.
+`)},
+		"partials/nested.md": {Data: []byte(`Nested start.
+
+{{< include "partials/warning.md" >}}
+
+Nested end.
+`)},
+		"partials/json.md": {Data: []byte("```json\n" +
+			`{"Severity":"low","Extra":true}` + "\n```\n\nJSON here.\n")},
+		"partials/end.md": {Data: []byte("Meta at end.\n\n    Ending: maybe\n")},
+		"cycle/a.md":      {Data: []byte(`{{< include "cycle/b.md" >}}`)},
+		"cycle/b.md":      {Data: []byte(`{{< include "./cycle/a.md" >}}`)},
+		"deep/1.md":       {Data: []byte(`{{< include "deep/2.md" >}}`)},
+		"deep/2.md":       {Data: []byte(`{{< include "deep/3.md" >}}`)},
+		"deep/3.md":       {Data: []byte("Deep.\n")},
+	}
+}
+
+func Test_Parse_Include(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# Doc
+
+    Tags: [doc]
+
+{{< include "partials/warning.md" >}}
+
+` + "```" + `
+{{< include "partials/warning.md" >}}
+` + "```" + `
+
+    {{< include "partials/warning.md" >}}
+
+## Warning
+`
+	expMap := map[string]interface{}{
+		"Title": "Doc",
+		"Tags":  []interface{}{"doc"},
+	}
+	expContent := `<h1 id="doc">Doc</h1>
+
+<h2 id="warning">Warning</h2>
+
+<p>Be careful out there.</p>
+
+<pre><code>{{&lt; include &quot;partials/warning.md&quot; &gt;}}
+</code></pre>
+
+<pre><code>{{&lt; include &quot;partials/warning.md&quot; &gt;}}
+</code></pre>
+
+<h2 id="warning-1">Warning</h2>
+`
+
+	parser := frostedmd.New()
+	parser.MarkdownExtensions |= blackfriday.EXTENSION_AUTO_HEADER_IDS
+	parser.FS = includeTestFS()
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(expMap, res.Meta, "meta map as expected, included stripped")
+	assert.Equal(expContent, string(res.Content),
+		"content as expected, code not expanded, heading IDs unique")
+
+}
+
+func Test_Parse_Include_MergeMeta(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# Doc
+
+    Severity: medium
+
+{{< include "partials/nested.md" >}}
+
+{{< include "partials/json.md" >}}
+`
+	expMap := map[string]interface{}{
+		"Title":    "Doc",
+		"Severity": "medium",
+		"Extra":    true,
+	}
+	expContent := `<h1>Doc</h1>
+
+<p>Nested start.</p>
+
+<h2>Warning</h2>
+
+<p>Be careful out there.</p>
+
+<p>Nested end.</p>
+
+<p>JSON here.</p>
+`
+
+	parser := frostedmd.New()
+	parser.FS = includeTestFS()
+	parser.IncludeMeta = frostedmd.IncludeMetaMerge
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(expMap, res.Meta, "meta merged, including document wins")
+	assert.Equal(expContent, string(res.Content), "content as expected")
+
+}
+
+func Test_Parse_Include_MetaAtEnd(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `Start.
+
+{{< include "partials/end.md" >}}
+
+    Ending: surely
+`
+	parser := frostedmd.New()
+	parser.MetaAtEnd = true
+	parser.FS = includeTestFS()
+	parser.IncludeMeta = frostedmd.IncludeMetaMerge
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(map[string]interface{}{"Ending": "surely"}, res.Meta,
+		"meta as expected")
+	assert.Equal("<p>Start.</p>\n\n<p>Meta at end.</p>\n",
+		string(res.Content), "included meta stripped from end")
+
+}
+
+func Test_Parse_Include_Depth(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := []byte(`{{< include "deep/1.md" >}}`)
+	parser := frostedmd.New()
+	parser.FS = includeTestFS()
+
+	res, err := parser.Parse(input)
+	assert.Nil(err, "no error at default depth")
+	assert.Equal("<p>Deep.</p>\n", string(res.Content), "content included")
+
+	parser.MaxIncludeDepth = 2
+	res, err = parser.Parse(input)
+	if assert.Error(err, "error returned") {
+		assert.Equal("Include error in deep/2.md on line 1: "+
+			"Include depth limit (2) exceeded: deep/3.md",
+			err.Error(), "error message as expected")
+	}
+	assert.Equal("<p>{{&lt; include &ldquo;deep/1.md&rdquo; &gt;}}</p>\n",
+		string(res.Content), "unexpanded content returned")
+
+}
+
+func Test_Parse_Include_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	parser.FS = includeTestFS()
+
+	for input, exp := range map[string]string{
+		"Hi.\n\n{{< include \"cycle/a.md\" >}}": "Include error in " +
+			"cycle/b.md on line 1: Include cycle: " +
+			"cycle/a.md -> cycle/b.md -> cycle/a.md",
+		"{{< include \"../up.md\" >}}": "Include error on line 1: " +
+			"Invalid include path: ../up.md",
+		"{{< include \"nope.md\" >}}": "Include error on line 1: " +
+			"open nope.md: file does not exist",
+	} {
+		_, err := parser.Parse([]byte(input))
+		if assert.Error(err, "error returned for "+input) {
+			assert.Equal(exp, err.Error(), "error as expected for "+input)
+			var ie frostedmd.IncludeError
+			assert.True(errors.As(err, &ie), "IncludeError for "+input)
+		}
+	}
+
+}
+
+func Test_Parse_Include_NoFS(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res, err := frostedmd.New().Parse([]byte(`{{< include "deep/3.md" >}}`))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal("<p>{{&lt; include &ldquo;deep/3.md&rdquo; &gt;}}</p>\n",
+		string(res.Content), "directive left alone")
+
+}
+
+func Test_Parse_Include_Twice(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Top\n\n{{< include \"partials/warning.md\" >}}\n\n" +
+		"{{< include \"partials/nested.md\" >}}\n"
+	parser := frostedmd.New()
+	parser.MarkdownExtensions |= blackfriday.EXTENSION_AUTO_HEADER_IDS
+	parser.FS = includeTestFS()
+
+	res, err := parser.Parse([]byte(input))
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	ids := []string{}
+	for _, h := range res.Headings {
+		ids = append(ids, h.ID)
+	}
+	assert.Equal([]string{"top", "warning", "warning-1"}, ids,
+		"heading IDs unique")
+	assert.Contains(string(res.Content), `<h2 id="warning-1">Warning</h2>`,
+		"unique ID in content")
+	assert.Equal([]string{"partials/nested.md", "partials/warning.md"},
+		res.Includes, "each include listed once")
+
+}
+
+func Test_Parse_Include_ErrorLines(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Doc\n\n{{< include \"partials/nested.md\" >}}\n\n" +
+		"Some {{< nope >}} here.\n"
+	parser := frostedmd.New()
+	parser.FS = includeTestFS()
+	parser.Shortcodes = testShortcodes()
+
+	_, err := parser.Parse([]byte(input))
+	assert.EqualError(err,
+		"Shortcode error on line 5, column 6: nope: Unknown shortcode.",
+		"shortcode error on line of original input")
+
+	parser.MetaAtEnd = true
+	_, err = parser.Parse([]byte(input + "\n    foo: [bar\n"))
+	var me frostedmd.MetaError
+	if assert.True(errors.As(err, &me), "MetaError returned") {
+		assert.Equal(7, me.Line, "meta error on line of original input")
+	}
+
+}
@@ -0,0 +1,226 @@
+// links.go - checking the links between documents.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Link is a link or image in a Markdown source, at the given line of the
+// file at Path.
+type Link struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Target string `json:"target"`
+	Image  bool   `json:"image,omitempty"`
+}
+
+// String returns the link in the usual "file:line: target" form.
+func (l Link) String() string {
+	return fmt.Sprintf("%s:%d: %s", l.Path, l.Line, l.Target)
+}
+
+// BrokenLink is a Link whose target does not exist, for the Reason given.
+type BrokenLink struct {
+	Link
+	Reason string `json:"reason"`
+}
+
+// String returns the broken link in the usual "file:line: target: reason"
+// form.
+func (l BrokenLink) String() string {
+	return l.Link.String() + ": " + l.Reason
+}
+
+// LinkReport describes the result of CheckLinks: the number of Files and
+// relative Links checked, the Broken links among them, and the External
+// links, which are not checked.  Links are in order of path and line.
+type LinkReport struct {
+	Files    int          `json:"files"`
+	Links    int          `json:"links"`
+	Broken   []BrokenLink `json:"broken"`
+	External []Link       `json:"external"`
+}
+
+var (
+	// Inline links and images: [text](target "title"), ![alt](<target>)
+	inlineLinkRegexp = regexp.MustCompile(
+		`(!?)\[[^\]]*\]\(\s*(?:<([^>]*)>|([^\s)]*))(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	// Reference definitions: [label]: target "title"
+	refLinkRegexp = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	// Links and images in HTML.
+	htmlLinkRegexp = regexp.MustCompile(`(?i)\b(href|src)\s*=\s*"([^"]*)"`)
+	// Autolinks, with or without angle brackets.
+	autoLinkRegexp = regexp.MustCompile(
+		`<([a-zA-Z][a-zA-Z0-9+.-]*:[^\s<>]+)>|\b(?:https?|ftp)://[^\s<>()"']+`)
+	// Code spans, whose contents are not links.
+	codeSpanRegexp = regexp.MustCompile("`+[^`]*`+")
+)
+
+// CheckLinks checks the relative links and images in all the Markdown files
+// under root in fsys, per the CollectionExtensions, skipping hidden files
+// and directories.  A link is broken if its target does not exist in fsys,
+// or if it has a fragment, and the target is a Markdown file without a
+// heading of that ID, as set for instance with "# Setup {#setup}".  A link
+// to a fragment alone refers to a heading in the same file.
+//
+// Links are relative to the file's directory, or to the root of fsys if
+// they begin with a slash.  Links with a scheme, such as "https:", or with
+// a host, are External: they are listed in the report but never fetched.
+//
+// Links are found in the source by approximate means, which cover the
+// common cases: inline links and images, reference definitions, HTML href
+// and src attributes, and autolinks; links in code are ignored.  An error
+// is only returned if a file can not be read.
+func (p *Parser) CheckLinks(fsys fs.FS, root string) (*LinkReport, error) {
+
+	report := &LinkReport{Broken: []BrokenLink{}, External: []Link{}}
+	ids := map[string]map[string]bool{}
+	headingIDs := func(name string) map[string]bool {
+		if m, ok := ids[name]; ok {
+			return m
+		}
+		m := map[string]bool{}
+		if res, _ := p.ParseFile(fsys, name); res != nil {
+			for _, h := range res.Headings {
+				if h.ID != "" {
+					m[h.ID] = true
+				}
+			}
+		}
+		ids[name] = m
+		return m
+	}
+
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !hasExtension(name, CollectionExtensions) {
+			return nil
+		}
+		input, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		report.Files++
+
+		for _, link := range sourceLinks(input) {
+			link.Path = name
+			if schemeRegexp.MatchString(link.Target) ||
+				strings.HasPrefix(link.Target, "//") {
+				report.External = append(report.External, link)
+				continue
+			}
+			report.Links++
+			if reason := checkLink(fsys, name, link.Target,
+				headingIDs); reason != "" {
+				report.Broken = append(report.Broken,
+					BrokenLink{Link: link, Reason: reason})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// checkLink returns the reason the relative target of a link in the named
+// file is broken, or the empty string if it is not.
+func checkLink(fsys fs.FS, name, target string, headingIDs func(string) map[string]bool) string {
+
+	ref, err := url.Parse(target)
+	if err != nil {
+		return "Invalid URL."
+	}
+	file := name
+	if ref.Path != "" {
+		if strings.HasPrefix(ref.Path, "/") {
+			file = path.Clean(strings.TrimPrefix(ref.Path, "/"))
+		} else {
+			file = path.Join(path.Dir(name), ref.Path)
+		}
+		if file == "" || strings.HasPrefix(file, "../") || file == ".." {
+			return "Target is outside the tree."
+		}
+		if _, err := fs.Stat(fsys, file); err != nil {
+			return "File not found."
+		}
+	}
+	if ref.Fragment == "" || !hasExtension(file, CollectionExtensions) {
+		return ""
+	}
+	if !headingIDs(file)[ref.Fragment] {
+		return fmt.Sprintf("No heading with ID %q in %s.", ref.Fragment,
+			file)
+	}
+	return ""
+}
+
+// sourceLinks returns the links found in the input, with their lines set,
+// in order.
+func sourceLinks(input []byte) []Link {
+
+	links := []Link{}
+	for _, b := range sourceBlocks(input) {
+		if b.kind != srcOther && b.kind != srcHeading {
+			continue
+		}
+		for i, line := range sourceLines(input[b.start:b.end]) {
+			add := func(target string, image bool) {
+				if target != "" {
+					links = append(links, Link{
+						Line:   b.line + i,
+						Target: target,
+						Image:  image,
+					})
+				}
+			}
+			// Matched spans are blanked out so that autolinks are not
+			// found again within them.
+			line = codeSpanRegexp.ReplaceAllFunc(line, blank)
+			if m := refLinkRegexp.FindSubmatch(line); m != nil {
+				add(string(m[1]), false)
+				line = blank(line)
+			}
+			line = inlineLinkRegexp.ReplaceAllFunc(line, func(s []byte) []byte {
+				m := inlineLinkRegexp.FindSubmatch(s)
+				add(string(m[2])+string(m[3]), len(m[1]) > 0)
+				return blank(s)
+			})
+			line = htmlLinkRegexp.ReplaceAllFunc(line, func(s []byte) []byte {
+				m := htmlLinkRegexp.FindSubmatch(s)
+				add(string(m[2]), strings.EqualFold(string(m[1]), "src"))
+				return blank(s)
+			})
+			for _, m := range autoLinkRegexp.FindAllSubmatch(line, -1) {
+				if len(m[1]) > 0 {
+					add(string(m[1]), false)
+				} else {
+					add(strings.TrimRight(string(m[0]), ".,;:!?"), false)
+				}
+			}
+		}
+	}
+	return links
+}
+
+// blank returns a copy of b with every byte replaced by a space.
+func blank(b []byte) []byte {
+	return bytes.Repeat([]byte(" "), len(b))
+}
@@ -0,0 +1,74 @@
+// links_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+var linksFS = fstest.MapFS{
+	"index.md": {Data: []byte("# Home {#home}\n\n" +
+		"See [the guide](guide/setup.md#install) and [missing](nope.md).\n" +
+		"![Logo](img/logo.png \"The logo\") ![Gone](<img/gone.png>)\n\n" +
+		"Back to [top](#home), or [nowhere](#nowhere).\n\n" +
+		"[ref]: guide/setup.md#bad-id\n\n" +
+		"Visit https://example.com/docs. or <mailto:me@example.com>.\n\n" +
+		"Not a link: `[x](code.md)`\n\n" +
+		"    [y](indented-code.md)\n\n" +
+		"<a href=\"guide/\">Guide</a> <img src=\"/img/logo.png\" alt=\"x\">\n")},
+	"guide/setup.md": {Data: []byte("# Setup\n\n## Install {#install}\n\n" +
+		"Up to [home](../index.md), out [of tree](../../x.md), " +
+		"[root](/index.md?x=1#home).\n")},
+	"img/logo.png":   {Data: []byte("PNG")},
+	".hidden/bad.md": {Data: []byte("[bad](nope.md)\n")},
+}
+
+func Test_CheckLinks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	report, err := frostedmd.New().CheckLinks(linksFS, ".")
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(2, report.Files, "files checked")
+	assert.Equal(12, report.Links, "relative links checked")
+
+	broken := []string{}
+	for _, link := range report.Broken {
+		broken = append(broken, link.String())
+	}
+	assert.Equal([]string{
+		"guide/setup.md:5: ../../x.md: Target is outside the tree.",
+		"index.md:3: nope.md: File not found.",
+		"index.md:4: img/gone.png: File not found.",
+		"index.md:6: #nowhere: No heading with ID \"nowhere\" in index.md.",
+		"index.md:8: guide/setup.md#bad-id: " +
+			"No heading with ID \"bad-id\" in guide/setup.md.",
+	}, broken, "broken links")
+	assert.True(report.Broken[2].Image, "image flagged")
+
+	external := []string{}
+	for _, link := range report.External {
+		external = append(external, link.String())
+	}
+	assert.Equal([]string{
+		"index.md:10: https://example.com/docs",
+		"index.md:10: mailto:me@example.com",
+	}, external, "external links")
+
+}
+
+func Test_CheckLinks_Error(t *testing.T) {
+
+	assert := assert.New(t)
+
+	_, err := frostedmd.New().CheckLinks(linksFS, "nope")
+	assert.Error(err, "error for missing root")
+
+}
@@ -0,0 +1,352 @@
+// lint.go - checking Markdown files for common problems.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LintRules are the rules checked by a Linter:
+//
+//	meta-error       the Meta Block can not be parsed
+//	parse-error      the document can not be parsed for another reason
+//	required-key     a Required key is missing from the Meta
+//	duplicate-title  the Title is the same as that of another document
+//	duplicate-slug   the Slug set in the Meta Block is the same as that of
+//	                 another document
+//	heading-skip     a heading is more than one level below the last one
+//	image-alt        an image has no alternative text
+//	empty            the document has no content besides headings
+var LintRules = []string{
+	"meta-error",
+	"parse-error",
+	"required-key",
+	"duplicate-title",
+	"duplicate-slug",
+	"heading-skip",
+	"image-alt",
+	"empty",
+}
+
+// LintFinding is a problem found by a Linter, at the given line of the
+// file at Path.
+type LintFinding struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// String returns the finding in the usual "file:line: rule: message" form.
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", f.Path, f.Line, f.Rule, f.Message)
+}
+
+// Linter checks Markdown files for the LintRules.  Most rules apply to each
+// file by itself, but titles and slugs are compared across all the files
+// checked by the Linter, which thus should not be reused for unrelated
+// sets of files.  Only slugs set in the Meta Block are compared, as those
+// derived from the path depend on the root it is relative to.
+//
+// Checks on the source, such as for headings and images, cover the common
+// cases rather than every possible Markdown construct.
+type Linter struct {
+	Parser   *Parser  // used to parse the files
+	Rules    []string // the rules to check; if nil, all LintRules
+	Required []string // the keys required in every Meta
+	titles   []lintKey
+	slugs    []lintKey
+}
+
+// lintKey is a title or slug seen by a Linter, with where it was seen.
+type lintKey struct {
+	value string
+	path  string
+	line  int
+}
+
+var (
+	imageNoAltRegexp = regexp.MustCompile(`!\[\s*\]\s*[\[(]`)
+	htmlImageRegexp  = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	htmlAltRegexp    = regexp.MustCompile(`(?i)\salt\s*=`)
+)
+
+// NewLinter returns a Linter checking all LintRules with a new Parser.
+func NewLinter() *Linter {
+	return &Linter{Parser: New()}
+}
+
+// CheckRules returns an error if any of the rules is not in LintRules.
+func CheckRules(rules []string) error {
+
+	for _, rule := range rules {
+		if !stringIn(rule, LintRules) {
+			return fmt.Errorf("Unknown lint rule: %s", rule)
+		}
+	}
+	return nil
+}
+
+// Lint checks all the Markdown files under root in fsys, per the
+// CollectionExtensions, skipping hidden files and directories.  The
+// findings are returned sorted by path and line, with the duplicates
+// among all the files checked so far.  An error is only returned if a
+// file can not be read.
+func (l *Linter) Lint(fsys fs.FS, root string) ([]LintFinding, error) {
+
+	findings := []LintFinding{}
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !hasExtension(name, CollectionExtensions) {
+			return nil
+		}
+		found, err := l.LintFile(fsys, name, name)
+		findings = append(findings, found...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, l.Duplicates()...)
+	SortFindings(findings)
+	return findings, nil
+}
+
+// LintFile checks the named file in fsys, reporting it as path, and
+// remembers its title and slug for Duplicates.  An error is only returned
+// if the file can not be read.
+func (l *Linter) LintFile(fsys fs.FS, name, path string) ([]LintFinding, error) {
+
+	// The file is read once, so that the findings all match one version.
+	input, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	parser := l.Parser
+	if parser == nil {
+		parser = New()
+	}
+	res, err := parser.parseFileInput(input, name, info)
+
+	findings := []LintFinding{}
+	add := func(line int, rule, format string, args ...interface{}) {
+		if line < 1 {
+			line = 1
+		}
+		if l.checks(rule) {
+			findings = append(findings, LintFinding{
+				Path:    path,
+				Line:    line,
+				Rule:    rule,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+	}
+
+	meta := locateMeta(input, parser.MetaAtEnd)
+	metaLine := 0
+	if meta != nil {
+		metaLine = meta.line
+	}
+	var me MetaError
+	if errors.As(err, &me) {
+		line := me.Line
+		if line == 0 {
+			line = metaLine
+		}
+		add(line, "meta-error", "%s", me.Err)
+	} else if err != nil {
+		add(1, "parse-error", "%s", err)
+	} else {
+		for _, key := range l.Required {
+			if metaValue(res.Meta, key) == nil {
+				add(metaLine, "required-key", "Missing required key %q.", key)
+			}
+		}
+	}
+
+	blocks := sourceBlocks(input)
+	lines := sourceLines(input)
+	level, content := 0, false
+	headingLine := 0
+	for _, b := range blocks {
+		switch b.kind {
+		case srcHeading:
+			if headingLine == 0 {
+				headingLine = b.line
+			}
+			n := headingLevel(lines, b.line)
+			if level > 0 && n > level+1 {
+				add(b.line, "heading-skip",
+					"Heading level %d follows level %d.", n, level)
+			}
+			level = n
+		case srcFenced, srcIndented:
+			if meta == nil || b.start != meta.start {
+				content = true
+			}
+			continue
+		case srcOther:
+			content = true
+		default:
+			continue
+		}
+		for i, line := range sourceLines(input[b.start:b.end]) {
+			if imageNoAltRegexp.Match(line) {
+				add(b.line+i, "image-alt", "Image has no alt text.")
+				continue
+			}
+			for _, img := range htmlImageRegexp.FindAll(line, -1) {
+				if !htmlAltRegexp.Match(img) {
+					add(b.line+i, "image-alt", "Image has no alt text.")
+					break
+				}
+			}
+		}
+	}
+	if !content {
+		add(1, "empty", "Document has no content.")
+	}
+
+	if title, ok := metaValue(res.Meta, "Title").(string); ok && title != "" {
+		line := metaKeyLine(meta, "Title")
+		if line == 0 {
+			line = headingLine
+		}
+		l.titles = append(l.titles, lintKey{title, path, line})
+	}
+	if slug, ok := metaValue(res.Meta, "Slug").(string); ok && slug != "" &&
+		l.metaHasKey(parser, meta, "Slug") {
+		line := metaKeyLine(meta, "Slug")
+		l.slugs = append(l.slugs, lintKey{slug, path, line})
+	}
+	return findings, nil
+}
+
+// Duplicates returns the findings for titles and slugs used by more than
+// one of the files checked so far.  The first file to use one is not
+// reported, only the others.
+func (l *Linter) Duplicates() []LintFinding {
+
+	findings := []LintFinding{}
+	check := func(keys []lintKey, rule, name string) {
+		if !l.checks(rule) {
+			return
+		}
+		first := map[string]string{}
+		for _, key := range keys {
+			if path, ok := first[key.value]; ok {
+				line := key.line
+				if line < 1 {
+					line = 1
+				}
+				findings = append(findings, LintFinding{
+					Path: key.path,
+					Line: line,
+					Rule: rule,
+					Message: fmt.Sprintf("%s %q is also used by %s.", name,
+						key.value, path),
+				})
+				continue
+			}
+			first[key.value] = key.path
+		}
+	}
+	check(l.titles, "duplicate-title", "Title")
+	check(l.slugs, "duplicate-slug", "Slug")
+	return findings
+}
+
+// metaHasKey returns true if the key is set in the Meta Block itself,
+// rather than derived from the file.
+func (l *Linter) metaHasKey(parser *Parser, meta *metaSource, key string) bool {
+
+	if meta == nil {
+		return false
+	}
+	mm, err := parser.parseMeta(meta.text, meta.lang)
+	return err == nil && metaValue(mm, key) != nil
+}
+
+// checks returns true if the Linter checks the rule.
+func (l *Linter) checks(rule string) bool {
+	return l.Rules == nil || stringIn(rule, l.Rules)
+}
+
+// SortFindings sorts the findings by path and line, keeping the order of
+// findings on the same line.
+func SortFindings(findings []LintFinding) {
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
+
+// headingLevel returns the level of the heading beginning on the given line
+// (from 1).
+func headingLevel(lines [][]byte, line int) int {
+
+	text := bytes.TrimLeft(lines[line-1], " ")
+	if text[0] == '#' {
+		return len(text) - len(bytes.TrimLeft(text, "#"))
+	}
+	if line < len(lines) && bytes.HasPrefix(bytes.TrimSpace(lines[line]),
+		[]byte("=")) {
+		return 1
+	}
+	return 2
+}
+
+// metaKeyLine returns the line on which the key appears in the Meta Block,
+// or zero if it is not found.  Keys are matched regardless of case and
+// quotes, at any depth, which is good enough for the usual flat blocks.
+func metaKeyLine(meta *metaSource, key string) int {
+
+	if meta == nil {
+		return 0
+	}
+	for i, line := range sourceLines(meta.text) {
+		text := strings.TrimLeft(strings.TrimSpace(string(line)), `"'`)
+		if len(text) <= len(key) || !strings.EqualFold(text[:len(key)], key) {
+			continue
+		}
+		rest := strings.TrimLeft(text[len(key):], `"' `)
+		if strings.HasPrefix(rest, ":") {
+			return meta.line + i
+		}
+	}
+	return 0
+}
+
+// stringIn returns true if s is in list.
+func stringIn(s string, list []string) bool {
+
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// lint_test.go
+
+package frostedmd_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+// lintFS has a file for every rule, and one with no problems.
+var lintFS = fstest.MapFS{
+	"good.md": {Data: []byte(
+		"# Good\n\n    Date: 2024-01-01\n    Slug: good\n\n" +
+			"A ![cat](cat.png).\n")},
+	"broken.md": {Data: []byte("# Broken\n\n    Date: [\n\nText.\n")},
+	"skip.md": {Data: []byte("# Skip\n\n    Date: 2024-01-01\n\n" +
+		"Intro.\n\n### Deep\n\n![](x.png)\n\n<img src=\"y.png\">\n\n" +
+		"<img alt=\"fine\" src=\"z.png\">\n\n```\n![](in-code.png)\n```\n")},
+	"empty.md": {Data: []byte("# Empty\n\n    Date: 2024-01-01\n")},
+	"twin.md": {Data: []byte(
+		"# Good\n\n    Date: 2024-01-01\n    Slug: good\n\nAgain.\n")},
+	"sub/nodate.md": {Data: []byte("No Date\n=======\n\nText.\n\n" +
+		"Part\n----\n\n#### Deeper\n")},
+	".hidden/x.md": {Data: []byte("")},
+}
+
+func Test_Linter_Lint(t *testing.T) {
+
+	assert := assert.New(t)
+
+	linter := frostedmd.NewLinter()
+	linter.Required = []string{"Date"}
+	findings, err := linter.Lint(lintFS, ".")
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	lines := []string{}
+	for _, f := range findings {
+		lines = append(lines, f.String())
+	}
+	assert.Equal([]string{
+		"broken.md:3: meta-error: yaml: line 1: did not find expected node content",
+		"empty.md:1: empty: Document has no content.",
+		"skip.md:7: heading-skip: Heading level 3 follows level 1.",
+		"skip.md:9: image-alt: Image has no alt text.",
+		"skip.md:11: image-alt: Image has no alt text.",
+		"sub/nodate.md:1: required-key: Missing required key \"Date\".",
+		"sub/nodate.md:9: heading-skip: Heading level 4 follows level 2.",
+		"twin.md:1: duplicate-title: Title \"Good\" is also used by good.md.",
+		"twin.md:4: duplicate-slug: Slug \"good\" is also used by good.md.",
+	}, lines, "findings as expected")
+
+}
+
+func Test_Linter_LintFile_Roots(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// As for "fmd lint a b": derived slugs are relative to each root, and
+	// thus the same for a/x.md and b/x.md.
+	fsys := fstest.MapFS{
+		"a/index.md": {Data: []byte("# A\n\nText.\n")},
+		"a/x.md":     {Data: []byte("# A X\n\n    Slug: same\n\nText.\n")},
+		"b/index.md": {Data: []byte("# B\n\nText.\n")},
+		"b/x.md":     {Data: []byte("# B X\n\n    Slug: same\n\nText.\n")},
+	}
+	linter := frostedmd.NewLinter()
+	for _, root := range []string{"a", "b"} {
+		sub, err := fs.Sub(fsys, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"index.md", "x.md"} {
+			found, err := linter.LintFile(sub, name, root+"/"+name)
+			assert.Nil(err, "no error")
+			assert.Empty(found, "no findings")
+		}
+	}
+	findings := linter.Duplicates()
+	if assert.Equal(1, len(findings), "one duplicate") {
+		assert.Equal("b/x.md:3: duplicate-slug: "+
+			"Slug \"same\" is also used by a/x.md.", findings[0].String(),
+			"only slugs from the Meta Block compared")
+	}
+
+}
+
+func Test_Linter_Rules(t *testing.T) {
+
+	assert := assert.New(t)
+
+	linter := frostedmd.NewLinter()
+	linter.Rules = []string{"image-alt", "duplicate-slug"}
+	findings, err := linter.Lint(lintFS, ".")
+	if assert.Nil(err, "no error") {
+		rules := []string{}
+		for _, f := range findings {
+			rules = append(rules, f.Rule)
+		}
+		assert.Equal([]string{"image-alt", "image-alt", "duplicate-slug"},
+			rules, "only the given rules checked")
+	}
+
+	assert.Nil(frostedmd.CheckRules([]string{"empty"}), "known rule ok")
+	assert.EqualError(frostedmd.CheckRules([]string{"empty", "nope"}),
+		"Unknown lint rule: nope", "unknown rule")
+
+}
+
+func Test_Linter_LintFile_Error(t *testing.T) {
+
+	assert := assert.New(t)
+
+	linter := frostedmd.NewLinter()
+	_, err := linter.LintFile(lintFS, "nope.md", "nope.md")
+	assert.Error(err, "error for missing file")
+
+}
+
+// readCountFS counts the files read from it.
+type readCountFS struct {
+	fstest.MapFS
+	reads map[string]int
+}
+
+func (f readCountFS) ReadFile(name string) ([]byte, error) {
+	f.reads[name]++
+	return f.MapFS.ReadFile(name)
+}
+
+func Test_Linter_Lint_ReadOnce(t *testing.T) {
+
+	assert := assert.New(t)
+
+	fsys := readCountFS{MapFS: lintFS, reads: map[string]int{}}
+	_, err := frostedmd.NewLinter().Lint(fsys, ".")
+	if assert.Nil(err, "no error") {
+		assert.Equal(6, len(fsys.reads), "all files read")
+		for name, n := range fsys.reads {
+			assert.Equal(1, n, "read once: %s", name)
+		}
+	}
+
+}
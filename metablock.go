@@ -0,0 +1,287 @@
+// metablock.go - locating the Meta Block in Markdown source.
+//
+// The renderer finds the Meta Block as part of the normal Markdown parse,
+// but sometimes we need to know where it is in the source: for instance
+// in order to strip it from included files.  This is the "less-canonical"
+// parsing mentioned in the TODO list, and covers the common cases rather
+// than every possible Markdown construct.
+
+package frostedmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// metaSource describes the location of a Meta Block in a Markdown source.
+type metaSource struct {
+	start  int    // offset of the start of the block, including any fence
+	end    int    // offset of the end of the block, including any fence
+	line   int    // line number (from 1) on which the block text begins
+	text   []byte // the block text, as the renderer would see it
+	lang   string // the code block info string, if any
+	fence  string // the fence marker, for fenced blocks
+	indent string // the indentation, for indented blocks
+}
+
+// Kinds of blocks in a source, for our limited purposes.
+const (
+	srcBlank = iota
+	srcFenced
+	srcIndented
+	srcHeading
+	srcOther
+)
+
+// sourceBlock is a very approximate Markdown block.
+type sourceBlock struct {
+	kind  int
+	start int
+	end   int
+	line  int
+	lines [][]byte // for code blocks, excluding fences
+	info  string   // for fenced blocks
+	fence string   // for fenced blocks
+}
+
+var (
+	atxHeadingRegexp    = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+	setextHeadingRegexp = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+)
+
+// sourceLines splits the input into lines, each including its newline.
+func sourceLines(input []byte) [][]byte {
+
+	lines := [][]byte{}
+	for pos := 0; pos < len(input); {
+		end := bytes.IndexByte(input[pos:], '\n')
+		if end < 0 {
+			end = len(input)
+		} else {
+			end += pos + 1
+		}
+		lines = append(lines, input[pos:end])
+		pos = end
+	}
+	return lines
+}
+
+// isIndented returns true if the line is indented as for a code block.
+func isIndented(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("    ")) ||
+		bytes.HasPrefix(line, []byte("\t"))
+}
+
+// isBlank returns true if the line contains nothing but whitespace.
+func isBlank(line []byte) bool {
+	return len(bytes.TrimSpace(line)) == 0
+}
+
+// sourceBlocks splits the input into approximate blocks.
+func sourceBlocks(input []byte) []*sourceBlock {
+
+	lines := sourceLines(input)
+	blocks := []*sourceBlock{}
+	var last *sourceBlock
+	add := func(kind, start, line int) *sourceBlock {
+		last = &sourceBlock{kind: kind, start: start, end: start, line: line}
+		blocks = append(blocks, last)
+		return last
+	}
+
+	pos := 0
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		lineNum := i + 1
+		fence := trackFence("", line)
+		switch {
+		case isBlank(line):
+			add(srcBlank, pos, lineNum)
+		case fence != "":
+			b := add(srcFenced, pos, lineNum)
+			b.fence = fence
+			b.info = strings.TrimSpace(strings.TrimLeft(
+				strings.TrimSpace(string(line)), fence[:1]))
+			b.info = strings.TrimSpace(
+				strings.TrimSuffix(strings.TrimPrefix(b.info, "{"), "}"))
+			for i+1 < len(lines) {
+				i++
+				pos += len(line)
+				line = lines[i]
+				if trackFence(fence, line) == "" {
+					break
+				}
+				b.lines = append(b.lines, line)
+			}
+		case isIndented(line) && (last == nil || last.kind != srcOther):
+			b := add(srcIndented, pos, lineNum)
+			b.lines = append(b.lines, line)
+			for i+1 < len(lines) {
+				// Blank lines only count if followed by more code.
+				j := i + 1
+				for j < len(lines) && isBlank(lines[j]) {
+					j++
+				}
+				if j == len(lines) || !isIndented(lines[j]) {
+					break
+				}
+				for ; i < j; i++ {
+					pos += len(line)
+					line = lines[i+1]
+					b.lines = append(b.lines, line)
+				}
+			}
+		case atxHeadingRegexp.Match(line):
+			add(srcHeading, pos, lineNum)
+		case setextHeadingRegexp.Match(line) && last != nil &&
+			last.kind == srcOther && last.line == lineNum-1:
+			last.kind = srcHeading
+		default:
+			if last == nil || last.kind != srcOther {
+				add(srcOther, pos, lineNum)
+			}
+		}
+		pos += len(line)
+		last.end = pos
+	}
+
+	return blocks
+}
+
+// isDataBlock returns true if the block is a named data block.
+func (b *sourceBlock) isDataBlock() bool {
+	name, _ := dataBlockInfo(b.info)
+	return b.kind == srcFenced && name != ""
+}
+
+// metaSource returns the metaSource for a code block.
+func (b *sourceBlock) metaSource() *metaSource {
+
+	ms := &metaSource{
+		start: b.start,
+		end:   b.end,
+		line:  b.line,
+		lang:  b.info,
+		fence: b.fence,
+	}
+	if b.kind == srcFenced {
+		ms.line++
+		ms.text = bytes.Join(b.lines, nil)
+		return ms
+	}
+
+	// Indented code loses its indentation, and its final newline is
+	// guaranteed by the renderer.
+	ms.indent = "    "
+	if b.lines[0][0] == '\t' {
+		ms.indent = "\t"
+	}
+	var text bytes.Buffer
+	for _, line := range b.lines {
+		if bytes.HasPrefix(line, []byte("\t")) {
+			line = line[1:]
+		} else {
+			for i := 0; i < 4 && len(line) > 0 && line[0] == ' '; i++ {
+				line = line[1:]
+			}
+		}
+		text.Write(line)
+	}
+	ms.text = text.Bytes()
+	if !bytes.HasSuffix(ms.text, []byte("\n")) {
+		ms.text = append(ms.text, '\n')
+	}
+	return ms
+}
+
+// locateMeta returns the location of the Meta Block in the input, at the
+// beginning or the end according to atEnd; or nil if there is none.
+func locateMeta(input []byte, atEnd bool) *metaSource {
+
+	blocks := sourceBlocks(input)
+	if atEnd {
+		for i := len(blocks) - 1; i >= 0; i-- {
+			b := blocks[i]
+			switch {
+			case b.kind == srcBlank || b.isDataBlock():
+				continue
+			case b.kind == srcFenced || b.kind == srcIndented:
+				return b.metaSource()
+			}
+			return nil
+		}
+		return nil
+	}
+
+	headings := 0
+	for _, b := range blocks {
+		switch {
+		case b.kind == srcBlank || b.isDataBlock():
+			continue
+		case b.kind == srcHeading && headings == 0:
+			headings++
+			continue
+		case b.kind == srcFenced || b.kind == srcIndented:
+			return b.metaSource()
+		}
+		return nil
+	}
+	return nil
+}
+
+// MetaError describes an error in the Meta Block, with the line number in
+// the source on which it occurred, or on which the block begins if the
+// decoder did not say.  The line is zero if the block could not be located.
+//
+// Its message is that of the decoder, so that the Line may be presented as
+// the caller sees fit.
+type MetaError struct {
+	Line int
+	Err  error
+}
+
+// Error stringifies the error per the error interface.
+func (e MetaError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying decoder error.
+func (e MetaError) Unwrap() error {
+	return e.Err
+}
+
+var yamlLineRegexp = regexp.MustCompile(`\bline (\d+):`)
+
+// newMetaError returns a MetaError for the error err in decoding the Meta
+// Block of the input.
+func newMetaError(input []byte, atEnd bool, err error) MetaError {
+
+	ms := locateMeta(input, atEnd)
+	if ms == nil {
+		return MetaError{Err: err}
+	}
+
+	// The decoders report lines, or offsets, within the block text.
+	line := 0
+	var offset int64 = -1
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		if m := yamlLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+	}
+	if offset >= 0 && offset <= int64(len(ms.text)) {
+		line = bytes.Count(ms.text[:offset], []byte("\n")) + 1
+	}
+	if line > 0 {
+		line--
+	}
+	return MetaError{Line: ms.line + line, Err: err}
+}
@@ -0,0 +1,773 @@
+// metaedit.go - editing the Meta Block in place.
+//
+// Edits are made to the text of the block, line by line for YAML and value
+// by value for JSON, so that everything not edited stays exactly as it was:
+// the rest of the document, the other keys, comments, the indentation and
+// the fences.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	// Third-Party:
+	"gopkg.in/yaml.v2"
+)
+
+// MetaOp is the operation of a MetaEdit.
+type MetaOp int
+
+const (
+	MetaSet   MetaOp = iota // set the Key to the Value
+	MetaUnset               // remove the Key
+	MetaAdd                 // add the Value to the list at Key
+)
+
+// MetaEdit is a change to the Meta Block, as made by EditMeta.
+type MetaEdit struct {
+	Op    MetaOp
+	Key   string
+	Value interface{}
+}
+
+// metaLine is a line of a Meta Block: its text as decoded, and the prefix
+// removed from it, i.e. the indentation of an indented code block.
+type metaLine struct {
+	prefix string
+	text   string
+}
+
+// metaSplice replaces text[start:end] of a Meta Block with repl.
+type metaSplice struct {
+	start int
+	end   int
+	repl  string
+}
+
+// yamlKeyRegexp matches a top-level YAML key, plain or quoted.
+var yamlKeyRegexp = regexp.MustCompile(
+	`^("[^"]*"|'[^']*'|[^\s"'#\-\[\]{}][^:#]*?)\s*:(\s|$)`)
+
+// metaIntRegexp matches a decimal integer as given on a command line.
+var metaIntRegexp = regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)$`)
+
+// ParseMetaValue returns the value of s as given on a command line, e.g.
+// in "Weight=3".  Only values that can mean one thing are converted:
+// decimal integers, the words "true" and "false", and flow-style lists such
+// as "[a, 2]" whose items are read the same way.  Everything else is a
+// string, thus "1.10", "no" and "~" are not changed by YAML's rules.
+func ParseMetaValue(s string) interface{} {
+
+	trimmed := strings.TrimSpace(s)
+	switch {
+	case trimmed == "true":
+		return true
+	case trimmed == "false":
+		return false
+	case metaIntRegexp.MatchString(trimmed):
+		if n, err := strconv.Atoi(trimmed); err == nil {
+			return n
+		}
+	case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+		// Items are decoded as strings, keeping their text as written.
+		var items []string
+		if err := yaml.Unmarshal([]byte(trimmed), &items); err != nil {
+			return s
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			list[i] = ParseMetaValue(item)
+		}
+		return list
+	}
+	return s
+}
+
+// EditMeta returns the input with the edits made to its Meta Block, in
+// order.  Keys are matched regardless of case if there is no exact match,
+// so "title" edits "Title".  Setting a key replaces its value, or adds it
+// at the end of the block; unsetting a key that does not exist does
+// nothing; and adding to a key appends the value to its list, unless it is
+// already there, turning a single value into a list and creating the list
+// if needed.
+//
+// A key containing dots, such as "Nested.a", which is not itself in the
+// Meta is a path into nested maps, as for metaValue and thus for queries.
+// Maps along the path are created as needed, and the top-level value is
+// rewritten with the change.
+//
+// The Meta Block keeps its language, JSON or YAML, its indentation and its
+// position, and the rest of the input is not changed.  Values are written
+// in flow style, e.g. "[a, b]", which works in both languages.  If there is
+// no Meta Block, a YAML one is added: after the title heading if there is
+// one, or at the end if the Parser's MetaAtEnd is set.
+//
+// It is an error to edit a Meta Block that can not be parsed, to add to a
+// key whose value is a map, or to edit a path through a value that is not
+// a map.
+func (p *Parser) EditMeta(input []byte, edits ...MetaEdit) ([]byte, error) {
+
+	for _, edit := range edits {
+		var err error
+		if input, err = p.editMeta(input, edit); err != nil {
+			return nil, err
+		}
+	}
+	return input, nil
+}
+
+// editMeta makes a single edit.
+func (p *Parser) editMeta(input []byte, edit MetaEdit) ([]byte, error) {
+
+	ms := locateMeta(input, p.MetaAtEnd)
+	if ms == nil {
+		pe, err := pathEdit(map[string]interface{}{}, edit)
+		if err != nil || pe == nil {
+			return input, err
+		}
+		return p.addMetaBlock(input, *pe), nil
+	}
+	mm := map[string]interface{}{}
+	if err := decodeBlock(ms.text, ms.lang, &mm, "meta block"); err != nil {
+		return nil, newMetaError(input, p.MetaAtEnd, err)
+	}
+	pe, err := pathEdit(mm, edit)
+	if err != nil || pe == nil {
+		return input, err
+	}
+	edit = *pe
+
+	bodyStart, lines := ms.bodyLines(input)
+	var text strings.Builder
+	for _, line := range lines {
+		text.WriteString(line.text)
+	}
+	isJSON := ms.lang == "json" ||
+		(ms.lang == "" && json.Unmarshal(ms.text, &struct{}{}) == nil)
+	var splice *metaSplice
+	if isJSON {
+		splice, err = jsonMetaSplice(text.String(), mm, edit)
+	} else {
+		splice, err = yamlMetaSplice(text.String(), mm, edit)
+	}
+	if err != nil || splice == nil {
+		return input, err
+	}
+
+	lines = spliceLines(lines, splice, ms.indent)
+	var body bytes.Buffer
+	for _, line := range lines {
+		body.WriteString(line.prefix)
+		body.WriteString(line.text)
+	}
+	bodyEnd := bodyStart
+	for _, line := range ms.origLines(input) {
+		bodyEnd += len(line)
+	}
+	out := append([]byte{}, input[:bodyStart]...)
+	out = append(out, body.Bytes()...)
+	return append(out, input[bodyEnd:]...), nil
+}
+
+// origLines returns the lines of the block body in the input.
+func (ms *metaSource) origLines(input []byte) [][]byte {
+
+	block := sourceLines(input[ms.start:ms.end])
+	if ms.fence == "" {
+		return block
+	}
+	body := [][]byte{}
+	size := 0
+	for _, line := range block[1:] {
+		if size >= len(ms.text) {
+			break
+		}
+		body = append(body, line)
+		size += len(line)
+	}
+	return body
+}
+
+// bodyLines returns the offset of the block body in the input, and its
+// lines.
+func (ms *metaSource) bodyLines(input []byte) (int, []metaLine) {
+
+	start := ms.start
+	if ms.fence != "" {
+		start += len(sourceLines(input[ms.start:ms.end])[0])
+	}
+	lines := []metaLine{}
+	for _, line := range ms.origLines(input) {
+		prefix := ""
+		if ms.fence == "" {
+			if bytes.HasPrefix(line, []byte("\t")) {
+				prefix = "\t"
+			} else {
+				n := 0
+				for n < 4 && n < len(line) && line[n] == ' ' {
+					n++
+				}
+				prefix = string(line[:n])
+			}
+		}
+		lines = append(lines, metaLine{prefix, string(line[len(prefix):])})
+	}
+	return start, lines
+}
+
+// spliceLines applies the splice to the lines.  Lines not touched by it are
+// kept as-is, and new lines are given the indent.
+func spliceLines(lines []metaLine, s *metaSplice, indent string) []metaLine {
+
+	// Line offsets, with the end of the text last.
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line.text)
+	}
+	total := offsets[len(lines)]
+	newLines := func(text, prefix string) []metaLine {
+		out := []metaLine{}
+		for i, b := range sourceLines([]byte(text)) {
+			p := indent
+			if i == 0 && prefix != "" {
+				p = prefix
+			}
+			if isBlank(b) {
+				p = ""
+			}
+			out = append(out, metaLine{p, string(b)})
+		}
+		return out
+	}
+
+	// A pure insertion at the start of a line leaves its neighbours alone.
+	if s.start == s.end {
+		for i, off := range offsets {
+			if off != s.start {
+				continue
+			}
+			if i == len(lines) && i > 0 &&
+				!strings.HasSuffix(lines[i-1].text, "\n") {
+				break
+			}
+			out := append([]metaLine{}, lines[:i]...)
+			out = append(out, newLines(s.repl, "")...)
+			return append(out, lines[i:]...)
+		}
+	}
+
+	// Otherwise the lines from the start to the end of the splice are
+	// rebuilt.
+	first, last := len(lines)-1, len(lines)-1
+	for i := range lines {
+		if s.start < offsets[i+1] {
+			first = i
+			break
+		}
+	}
+	for i := first; i < len(lines); i++ {
+		if s.end <= offsets[i+1] && (s.end > offsets[i] || i == first) {
+			last = i
+			break
+		}
+	}
+	if s.end > total {
+		last = len(lines) - 1
+	}
+	text := ""
+	for _, line := range lines[first : last+1] {
+		text += line.text
+	}
+	base := offsets[first]
+	text = text[:s.start-base] + s.repl + text[s.end-base:]
+	out := append([]metaLine{}, lines[:first]...)
+	out = append(out, newLines(text, lines[first].prefix)...)
+	return append(out, lines[last+1:]...)
+}
+
+// addMetaBlock returns the input with a new YAML Meta Block for the edit,
+// set off from its neighbours by blank lines.
+func (p *Parser) addMetaBlock(input []byte, edit MetaEdit) []byte {
+
+	value := edit.Value
+	if edit.Op == MetaAdd {
+		value = []interface{}{value}
+	}
+	block := "    " + yamlKey(edit.Key) + ": " + yamlValue(value, false) + "\n"
+
+	pos := len(input)
+	if !p.MetaAtEnd {
+		headings := 0
+		for _, b := range sourceBlocks(input) {
+			if b.kind == srcBlank {
+				continue
+			}
+			if b.kind == srcHeading && headings == 0 {
+				headings++
+				continue
+			}
+			pos = b.start
+			break
+		}
+		if headings == 0 {
+			pos = 0
+		}
+	}
+	before := input[:pos]
+	if len(before) > 0 && !bytes.HasSuffix(before, []byte("\n\n")) {
+		block = "\n" + block
+		if !bytes.HasSuffix(before, []byte("\n")) {
+			block = "\n" + block
+		}
+	}
+	if pos < len(input) {
+		block += "\n"
+	}
+	out := append([]byte{}, before...)
+	out = append(out, block...)
+	return append(out, input[pos:]...)
+}
+
+// metaKey returns the key in mm matching key, exactly or else regardless
+// of case, and whether there is one.
+func metaKey(mm map[string]interface{}, key string) (string, bool) {
+
+	if _, ok := mm[key]; ok {
+		return key, true
+	}
+	for k := range mm {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return key, false
+}
+
+// pathEdit returns the edit to make for a key which may be a path into
+// nested maps: if so, the edit sets the top-level key to a copy of its
+// value with the change made.  Nil is returned if there is nothing to do.
+func pathEdit(mm map[string]interface{}, edit MetaEdit) (*MetaEdit, error) {
+
+	if _, exists := metaKey(mm, edit.Key); exists ||
+		!strings.Contains(edit.Key, ".") {
+		if edit.Op == MetaUnset && !exists {
+			return nil, nil
+		}
+		return &edit, nil
+	}
+	path := strings.Split(edit.Key, ".")
+	for _, name := range path {
+		if name == "" {
+			return nil, fmt.Errorf("Invalid key: %s", edit.Key)
+		}
+	}
+	top, _ := metaKey(mm, path[0])
+	v, changed, err := editPath(mm[top], path[1:], top, edit)
+	if err != nil || !changed {
+		return nil, err
+	}
+	return &MetaEdit{Op: MetaSet, Key: top, Value: v}, nil
+}
+
+// editPath returns a copy of the map v, named name, with the edit made at
+// the path within it, and whether anything changed.
+func editPath(v interface{}, path []string, name string, edit MetaEdit) (interface{}, bool, error) {
+
+	var m map[string]interface{}
+	switch v := v.(type) {
+	case nil:
+		if edit.Op == MetaUnset {
+			return nil, false, nil
+		}
+		m = map[string]interface{}{}
+	case map[interface{}]interface{}:
+		m = stringKeys(v)
+	case map[string]interface{}:
+		m = make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = item
+		}
+	default:
+		return nil, false, fmt.Errorf("Cannot edit %s: %s is not a map.",
+			edit.Key, name)
+	}
+
+	key, exists := metaKey(m, path[0])
+	if len(path) > 1 {
+		sub, changed, err := editPath(m[key], path[1:], name+"."+key, edit)
+		if err != nil || !changed {
+			return nil, false, err
+		}
+		m[key] = sub
+		return m, true, nil
+	}
+	switch edit.Op {
+	case MetaSet:
+		m[key] = edit.Value
+	case MetaUnset:
+		if !exists {
+			return nil, false, nil
+		}
+		delete(m, key)
+	case MetaAdd:
+		added, changed, err := addedValue(edit.Key, m[key], edit.Value)
+		if err != nil || !changed {
+			return nil, false, err
+		}
+		m[key] = added
+	}
+	return m, true, nil
+}
+
+// addedValue returns the new value for adding v to cur, or false if v is
+// already in cur.
+func addedValue(key string, cur, v interface{}) (interface{}, bool, error) {
+
+	switch cur := cur.(type) {
+	case []interface{}:
+		for _, item := range cur {
+			if fmt.Sprint(item) == fmt.Sprint(v) {
+				return nil, false, nil
+			}
+		}
+		return append(cur, v), true, nil
+	case map[string]interface{}, map[interface{}]interface{}:
+		return nil, false, fmt.Errorf("Cannot add to %s: not a list.", key)
+	case nil:
+		return []interface{}{v}, true, nil
+	default:
+		if fmt.Sprint(cur) == fmt.Sprint(v) {
+			return nil, false, nil
+		}
+		return []interface{}{cur, v}, true, nil
+	}
+}
+
+// yamlSpan is a top-level key of a YAML Meta Block, from the start of its
+// line to the end of its value.
+type yamlSpan struct {
+	key   string // as written, with any quotes
+	start int
+	end   int
+	lines []string
+}
+
+// yamlSpans returns the top-level keys of the YAML text.
+func yamlSpans(text string) []*yamlSpan {
+
+	spans := []*yamlSpan{}
+	var cur *yamlSpan
+	off := 0
+	for _, b := range sourceLines([]byte(text)) {
+		line := string(b)
+		switch {
+		case cur != nil && (isBlank(b) || line[0] == ' ' ||
+			line[0] == '\t' || strings.HasPrefix(line, "- ") ||
+			strings.TrimSpace(line) == "-"):
+			if !isBlank(b) {
+				cur.end = off + len(line)
+				cur.lines = append(cur.lines, line)
+			}
+		case yamlKeyRegexp.MatchString(line):
+			m := yamlKeyRegexp.FindStringSubmatch(line)
+			cur = &yamlSpan{key: m[1], start: off, end: off + len(line),
+				lines: []string{line}}
+			spans = append(spans, cur)
+		default:
+			cur = nil
+		}
+		off += len(line)
+	}
+	return spans
+}
+
+// yamlMetaSplice returns the splice making the edit to the YAML text, or
+// nil if there is nothing to change.
+func yamlMetaSplice(text string, mm map[string]interface{}, edit MetaEdit) (*metaSplice, error) {
+
+	key, exists := metaKey(mm, edit.Key)
+	var span *yamlSpan
+	for _, s := range yamlSpans(text) {
+		if strings.Trim(s.key, `"'`) == key {
+			span = s
+		}
+	}
+	if exists && span == nil {
+		return nil, fmt.Errorf("Cannot edit %s: not found in the source.",
+			key)
+	}
+
+	value := edit.Value
+	switch edit.Op {
+	case MetaUnset:
+		if span == nil {
+			return nil, nil
+		}
+		return &metaSplice{span.start, span.end, ""}, nil
+	case MetaAdd:
+		var changed bool
+		var err error
+		value, changed, err = addedValue(key, mm[key], edit.Value)
+		if err != nil || !changed {
+			return nil, err
+		}
+		if span == nil {
+			break
+		}
+		if _, ok := mm[key].([]interface{}); !ok {
+			break
+		}
+		item := yamlValue(edit.Value, true)
+		first := span.lines[0]
+		rest := strings.TrimSpace(first[strings.Index(first, ":")+1:])
+		if len(span.lines) == 1 && strings.HasPrefix(rest, "[") &&
+			strings.HasSuffix(rest, "]") {
+			// Flow list: add before the closing bracket.
+			end := span.start + strings.LastIndex(first, "]")
+			inner := strings.TrimRight(text[:end], " \t")
+			if strings.HasSuffix(inner, "[") {
+				return &metaSplice{end, end, item}, nil
+			}
+			return &metaSplice{len(inner), len(inner), ", " + item}, nil
+		}
+		if rest == "" && len(span.lines) > 1 {
+			// Block list: add a line like the last item.
+			for i := len(span.lines) - 1; i > 0; i-- {
+				line := span.lines[i]
+				dash := strings.Index(line, "-")
+				if dash < 0 || strings.TrimSpace(line[:dash]) != "" {
+					continue
+				}
+				n := dash + 1
+				for n < len(line) && line[n] == ' ' {
+					n++
+				}
+				repl := line[:n] + item + "\n"
+				if !strings.HasSuffix(text[:span.end], "\n") {
+					repl = "\n" + repl
+				}
+				return &metaSplice{span.end, span.end, repl}, nil
+			}
+		}
+		// Anything else is replaced in flow style.
+	}
+
+	if span != nil {
+		repl := span.key + ": " + yamlValue(value, false)
+		if strings.HasSuffix(text[:span.end], "\n") {
+			repl += "\n"
+		}
+		return &metaSplice{span.start, span.end, repl}, nil
+	}
+	end := len(strings.TrimRight(text, " \t\r\n"))
+	if end < len(text) {
+		end += strings.IndexByte(text[end:], '\n') + 1
+	}
+	repl := yamlKey(key) + ": " + yamlValue(value, false) + "\n"
+	if end > 0 && text[end-1] != '\n' {
+		repl = "\n" + repl
+	}
+	return &metaSplice{end, end, repl}, nil
+}
+
+// yamlKey returns the key as written in YAML, quoted if need be.
+func yamlKey(key string) string {
+
+	if key != "" && yamlKeyRegexp.MatchString(key+": x") &&
+		strings.TrimSpace(key) == key {
+		return key
+	}
+	return jsonText(key)
+}
+
+// yamlValue returns v as written in YAML, in flow style.  Inside lists and
+// maps, strings with flow indicators are quoted.
+func yamlValue(v interface{}, inFlow bool) string {
+
+	switch v := v.(type) {
+	case string:
+		var back interface{}
+		err := yaml.Unmarshal([]byte(v), &back)
+		if err != nil || back != v || v != strings.TrimSpace(v) ||
+			strings.ContainsAny(v, "\n#") || strings.Contains(v, ": ") ||
+			(inFlow && strings.ContainsAny(v, ",[]{}")) {
+			return jsonText(v)
+		}
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = yamlValue(item, true)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[interface{}]interface{}:
+		return yamlValue(stringKeys(v), inFlow)
+	case map[string]interface{}:
+		keys := []string{}
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = yamlKey(k) + ": " + yamlValue(v[k], true)
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case nil:
+		return "null"
+	default:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSpace(string(b))
+	}
+}
+
+// jsonText returns v as compact JSON, without escaping HTML.
+func jsonText(v interface{}) string {
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(jsonValue(v)); err != nil {
+		return fmt.Sprintf("%q", fmt.Sprint(v))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// jsonMember is a top-level member of a JSON Meta Block.
+type jsonMember struct {
+	key        string
+	keyStart   int
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// jsonMembers returns the offsets of the braces of the JSON object in the
+// text, and its members.
+func jsonMembers(text string) (int, int, []jsonMember, error) {
+
+	dec := json.NewDecoder(strings.NewReader(text))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return 0, 0, nil, errors.New("Meta Block is not a JSON object.")
+	}
+	open := int(dec.InputOffset()) - 1
+	members := []jsonMember{}
+	for dec.More() {
+		m := jsonMember{keyStart: int(dec.InputOffset())}
+		t, err := dec.Token()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		m.key, _ = t.(string)
+		m.keyStart += strings.IndexByte(text[m.keyStart:], '"')
+		m.keyEnd = int(dec.InputOffset())
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return 0, 0, nil, err
+		}
+		m.valueEnd = int(dec.InputOffset())
+		m.valueStart = m.valueEnd - len(raw)
+		members = append(members, m)
+	}
+	if _, err := dec.Token(); err != nil {
+		return 0, 0, nil, err
+	}
+	return open, int(dec.InputOffset()) - 1, members, nil
+}
+
+// jsonMetaSplice returns the splice making the edit to the JSON text, or
+// nil if there is nothing to change.
+func jsonMetaSplice(text string, mm map[string]interface{}, edit MetaEdit) (*metaSplice, error) {
+
+	open, close, members, err := jsonMembers(text)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := metaKey(mm, edit.Key)
+	idx := -1
+	for i, m := range members {
+		if m.key == key {
+			idx = i
+		}
+	}
+
+	value := edit.Value
+	switch edit.Op {
+	case MetaUnset:
+		switch {
+		case idx < 0:
+			return nil, nil
+		case idx > 0:
+			return &metaSplice{members[idx-1].valueEnd,
+				members[idx].valueEnd, ""}, nil
+		case len(members) > 1:
+			return &metaSplice{members[0].keyStart, members[1].keyStart,
+				""}, nil
+		}
+		return &metaSplice{members[0].keyStart, members[0].valueEnd, ""}, nil
+	case MetaAdd:
+		var changed bool
+		value, changed, err = addedValue(key, mm[key], edit.Value)
+		if err != nil || !changed {
+			return nil, err
+		}
+		if _, ok := mm[key].([]interface{}); ok && idx >= 0 {
+			m := members[idx]
+			raw := text[m.valueStart:m.valueEnd]
+			end := len(strings.TrimRight(text[:m.valueEnd-1], " \t\r\n"))
+			item := jsonText(edit.Value)
+			switch {
+			case text[end-1] == '[':
+				return &metaSplice{end, end, item}, nil
+			case strings.Contains(raw, "\n"):
+				return &metaSplice{end, end, ",\n" + lineIndent(text, end-1) +
+					item}, nil
+			case strings.Contains(raw, ",") && !strings.Contains(raw, ", "):
+				return &metaSplice{end, end, "," + item}, nil
+			}
+			return &metaSplice{end, end, ", " + item}, nil
+		}
+	}
+
+	if idx >= 0 {
+		m := members[idx]
+		return &metaSplice{m.valueStart, m.valueEnd, jsonText(value)}, nil
+	}
+	colon := ": "
+	if len(members) > 0 {
+		colon = text[members[0].keyEnd:members[0].valueStart]
+	}
+	member := jsonText(key) + colon + jsonText(value)
+	if len(members) == 0 {
+		return &metaSplice{open + 1, open + 1, member}, nil
+	}
+	last := members[len(members)-1]
+	sep := ", "
+	if strings.Contains(text[open:close], "\n") {
+		sep = ",\n" + lineIndent(text, last.keyStart)
+	}
+	return &metaSplice{last.valueEnd, last.valueEnd, sep + member}, nil
+}
+
+// lineIndent returns the leading whitespace of the line containing the
+// offset in text.
+func lineIndent(text string, offset int) string {
+
+	start := strings.LastIndexByte(text[:offset], '\n') + 1
+	end := start
+	for end < len(text) && (text[end] == ' ' || text[end] == '\t') {
+		end++
+	}
+	return text[start:end]
+}
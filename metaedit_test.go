@@ -0,0 +1,244 @@
+// metaedit_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_ParseMetaValue(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for s, exp := range map[string]interface{}{
+		"foo":        "foo",
+		"":           "",
+		"3":          3,
+		"true":       true,
+		"false":      false,
+		"-12":        -12,
+		"~":          "~",
+		"y":          "y",
+		"no":         "no",
+		"True":       "True",
+		"1.10":       "1.10",
+		"007":        "007",
+		"0x1F":       "0x1F",
+		"[a, 2]":     []interface{}{"a", 2},
+		"[y, 1.10]":  []interface{}{"y", "1.10"},
+		"[]":         []interface{}{},
+		"a: b":       "a: b",
+		"- a":        "- a",
+		"{a: 1}":     "{a: 1}",
+		"[bad":       "[bad",
+		"[[a], b]":   "[[a], b]",
+		"Hello, you": "Hello, you",
+	} {
+		assert.Equal(exp, frostedmd.ParseMetaValue(s), "value for %q", s)
+	}
+}
+
+func Test_EditMeta_YAML(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# The Title\n\n" +
+		"    Title: Old Title # a comment\n" +
+		"    Tags:\n" +
+		"      - a\n" +
+		"      - b\n" +
+		"    Draft: true\n\n" +
+		"Some *text*  \nwith trailing spaces.\n\n    code: here\n"
+	parser := frostedmd.New()
+
+	res, err := parser.EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "title", Value: "New: Title"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Tags", Value: "c"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Tags", Value: "a"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaUnset, Key: "Draft"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaUnset, Key: "Nope"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Weight", Value: 3},
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Authors", Value: "me"},
+	)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal("# The Title\n\n"+
+		"    Title: \"New: Title\"\n"+
+		"    Tags:\n"+
+		"      - a\n"+
+		"      - b\n"+
+		"      - c\n"+
+		"    Weight: 3\n"+
+		"    Authors: [me]\n\n"+
+		"Some *text*  \nwith trailing spaces.\n\n    code: here\n",
+		string(res), "block edited in place")
+
+	parsed, err := parser.Parse(res)
+	if assert.Nil(err, "edited doc parses") {
+		assert.Equal("New: Title", parsed.Meta["Title"], "title parsed")
+		assert.Equal([]interface{}{"a", "b", "c"}, parsed.Meta["Tags"],
+			"tags parsed")
+		assert.Equal([]interface{}{"me"}, parsed.Meta["Authors"],
+			"authors parsed")
+	}
+}
+
+func Test_EditMeta_YAMLFenced(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "```yaml\nTags: [a, b]\nCount: 1\n```\n\n# Title\n\nText."
+	res, err := frostedmd.New().EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Tags", Value: "c d"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Count", Value: 2},
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "List",
+			Value: []interface{}{"x, y", 1}},
+	)
+	if assert.Nil(err, "no error") {
+		assert.Equal("```yaml\nTags: [a, b, c d]\nCount: [1, 2]\n"+
+			"List: [\"x, y\", 1]\n```\n\n# Title\n\nText.",
+			string(res), "fenced block edited")
+	}
+
+	_, err = frostedmd.New().EditMeta([]byte("    Map: {a: 1}\n"),
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Map", Value: "b"})
+	if assert.Error(err, "error for adding to map") {
+		assert.Equal("Cannot add to Map: not a list.", err.Error(),
+			"error message")
+	}
+
+	_, err = frostedmd.New().EditMeta([]byte("    Bad: [\n"),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Bad", Value: 1})
+	assert.IsType(frostedmd.MetaError{}, err, "MetaError for bad block")
+}
+
+func Test_EditMeta_JSON(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Title\n\n" +
+		"```json\n{\n  \"Title\" : \"Old\",\n  \"Tags\": [\n    \"a\"\n  ],\n" +
+		"  \"Draft\": true\n}\n```\n\nText & <b>more</b>.\n"
+	res, err := frostedmd.New().EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Title", Value: "<New>"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Tags", Value: "b"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaUnset, Key: "Draft"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Weight", Value: 3},
+	)
+	if assert.Nil(err, "no error") {
+		assert.Equal("# Title\n\n"+
+			"```json\n{\n  \"Title\" : \"<New>\",\n  \"Tags\": [\n    \"a\",\n"+
+			"    \"b\"\n  ],\n  \"Weight\" : 3\n}\n```\n\nText & <b>more</b>.\n",
+			string(res), "multiline JSON edited")
+	}
+
+	input = "    {\"Tags\":[],\"Title\":\"x\"}\n\n# Text\n"
+	res, err = frostedmd.New().EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Tags", Value: "a"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Tags", Value: "b"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaUnset, Key: "Tags"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Tags", Value: 1},
+	)
+	if assert.Nil(err, "no error") {
+		assert.Equal("    {\"Title\":\"x\", \"Tags\":1}\n\n# Text\n",
+			string(res), "single-line JSON edited")
+	}
+}
+
+func Test_EditMeta_AtEnd(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	parser.MetaAtEnd = true
+	input := "# Title\n\nText.\n\n\tTitle: Old\n"
+	res, err := parser.EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Title", Value: "New"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Draft", Value: true},
+	)
+	if assert.Nil(err, "no error") {
+		assert.Equal("# Title\n\nText.\n\n\tTitle: New\n\tDraft: true\n",
+			string(res), "block at end edited")
+	}
+
+	res, err = parser.EditMeta([]byte("Text."),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Title", Value: "T"})
+	if assert.Nil(err, "no error") {
+		assert.Equal("Text.\n\n    Title: T\n", string(res),
+			"block added at end")
+	}
+}
+
+func Test_EditMeta_NoBlock(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	for input, exp := range map[string]string{
+		"":                   "    Tags: [a]\n",
+		"Text.\n":            "    Tags: [a]\n\nText.\n",
+		"# Title":            "# Title\n\n    Tags: [a]\n",
+		"# Title\nText.\n":   "# Title\n\n    Tags: [a]\n\nText.\n",
+		"# Title\n\nText.\n": "# Title\n\n    Tags: [a]\n\nText.\n",
+	} {
+		res, err := parser.EditMeta([]byte(input),
+			frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Tags", Value: "a"})
+		if assert.Nil(err, "no error for %q", input) {
+			assert.Equal(exp, string(res), "block added to %q", input)
+		}
+	}
+
+	res, err := parser.EditMeta([]byte("Text.\n"),
+		frostedmd.MetaEdit{Op: frostedmd.MetaUnset, Key: "Tags"})
+	if assert.Nil(err, "no error") {
+		assert.Equal("Text.\n", string(res), "unset does nothing")
+	}
+}
+
+func Test_EditMeta_Path(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Title\n\n    Nested:\n      a: 1 # one\n      b: two\n" +
+		"    \"og.title\": Literal\n\nText.\n"
+	parser := frostedmd.New()
+	res, err := parser.EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Nested.a", Value: 5},
+		frostedmd.MetaEdit{Op: frostedmd.MetaUnset, Key: "nested.B"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaAdd, Key: "Nested.c.Tags", Value: "x"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "og.title", Value: "New"},
+		frostedmd.MetaEdit{Op: frostedmd.MetaUnset, Key: "Nope.a"},
+	)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal("# Title\n\n    Nested: {a: 5, c: {Tags: [x]}}\n"+
+		"    \"og.title\": New\n\nText.\n", string(res), "nested map edited")
+
+	pr, err := parser.Parse(res)
+	if assert.Nil(err, "no parse error") {
+		match, _ := frostedmd.Where(
+			`Nested.a == 5 and Nested.c.Tags contains "x"`)
+		assert.True(match(pr), "values read back by path")
+	}
+
+	res, err = parser.EditMeta([]byte("# Title\n\nText.\n"),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Nested.a", Value: 5})
+	if assert.Nil(err, "no error without block") {
+		assert.Equal("# Title\n\n    Nested: {a: 5}\n\nText.\n", string(res),
+			"block added with nested map")
+	}
+
+	_, err = parser.EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Nested.b.x", Value: 1})
+	assert.EqualError(err, "Cannot edit Nested.b.x: Nested.b is not a map.",
+		"error for path through a string")
+	_, err = parser.EditMeta([]byte(input),
+		frostedmd.MetaEdit{Op: frostedmd.MetaSet, Key: "Nested..x", Value: 1})
+	assert.EqualError(err, "Invalid key: Nested..x", "error for empty name")
+}
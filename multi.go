@@ -0,0 +1,138 @@
+// multi.go - multiple documents in a single source.
+
+package frostedmd
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DefaultMultiSeparator is the separator line used by ParseMulti if the
+// Parser has no Splitter defined.
+const DefaultMultiSeparator = "+++"
+
+// Splitter splits a multi-document source into its component documents.
+type Splitter func(input []byte) [][]byte
+
+// SplitOnLine returns a Splitter that splits its input on lines consisting
+// only of sep, plus any surrounding whitespace.  The separator lines are
+// not included in the output.  Lines within fenced code blocks are never
+// treated as separators.
+func SplitOnLine(sep string) Splitter {
+
+	marker := []byte(sep)
+	return func(input []byte) [][]byte {
+		return splitLines(input, func(line []byte) (bool, bool) {
+			return bytes.Equal(bytes.TrimSpace(line), marker), false
+		})
+	}
+}
+
+// SplitOnHeading returns a Splitter that splits its input before every
+// ATX-style heading of the given level, e.g. "# Title" for level 1.  The
+// heading is kept as the first line of the document that follows it, and
+// is thus available as the document's title.  Headings within fenced code
+// blocks are ignored.
+func SplitOnHeading(level int) Splitter {
+
+	marker := append(bytes.Repeat([]byte{'#'}, level), ' ')
+	return func(input []byte) [][]byte {
+		return splitLines(input, func(line []byte) (bool, bool) {
+			return bytes.HasPrefix(line, marker), true
+		})
+	}
+}
+
+// splitLines splits the input into chunks at lines for which split returns
+// true, keeping the line itself if keep is also true.  Fenced code blocks
+// are respected, and chunks containing only whitespace are discarded.
+func splitLines(input []byte, split func(line []byte) (bool, bool)) [][]byte {
+
+	chunks := [][]byte{}
+	add := func(chunk []byte) {
+		if len(bytes.TrimSpace(chunk)) > 0 {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	fence := ""
+	start := 0
+	for pos := 0; pos < len(input); {
+		end := bytes.IndexByte(input[pos:], '\n')
+		if end < 0 {
+			end = len(input)
+		} else {
+			end += pos + 1
+		}
+		line := input[pos:end]
+		fence = trackFence(fence, line)
+		if fence == "" {
+			if isSplit, keep := split(line); isSplit {
+				add(input[start:pos])
+				if keep {
+					start = pos
+				} else {
+					start = end
+				}
+			}
+		}
+		pos = end
+	}
+	add(input[start:])
+
+	return chunks
+}
+
+// trackFence returns the fence marker in effect after line, given the
+// marker in effect before it; the empty string means we are not in a fenced
+// code block.
+func trackFence(fence string, line []byte) string {
+
+	trimmed := bytes.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return fence
+	}
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == c {
+			n++
+		}
+		if n < 3 {
+			continue
+		}
+		marker := string(trimmed[:n])
+		if fence == "" {
+			return marker
+		}
+		if fence[0] == c && n >= len(fence) &&
+			len(bytes.TrimSpace(trimmed[n:])) == 0 {
+			return ""
+		}
+	}
+	return fence
+}
+
+// ParseMulti splits the input into multiple documents using the Parser's
+// Splitter, or if that is nil then on lines of DefaultMultiSeparator, and
+// parses each of them in turn.  Each document may have its own Meta Block
+// and title.  As with Parse, all results are returned even if errors are
+// encountered; the error returned is that of the first failing document.
+func (p *Parser) ParseMulti(input []byte) ([]*ParseResult, error) {
+
+	split := p.Splitter
+	if split == nil {
+		split = SplitOnLine(DefaultMultiSeparator)
+	}
+
+	var firstErr error
+	results := []*ParseResult{}
+	for i, chunk := range split(input) {
+		res, err := p.Parse(chunk)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("Document %d: %s", i+1, err)
+		}
+		results = append(results, res)
+	}
+
+	return results, firstErr
+}
@@ -0,0 +1,99 @@
+// multi_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_ParseMulti_DefaultSeparator(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := readTestFile("multi.md")
+
+	res, err := frostedmd.New().ParseMulti(input)
+
+	assert.Nil(err, "no error returned")
+	if assert.Equal(2, len(res), "two results") {
+		assert.Equal(map[string]interface{}{
+			"Title": "First Entry",
+			"Tags":  []interface{}{"one"},
+		}, res[0].Meta, "first meta")
+		assert.Equal("<h1>First Entry</h1>\n\n<p>The first.</p>\n",
+			string(res[0].Content), "first content")
+		assert.Equal(map[string]interface{}{
+			"Title": "Second Entry",
+		}, res[1].Meta, "second meta")
+		assert.Equal("<h1>Second Entry</h1>\n\n<p>The second.</p>\n\n"+
+			"<pre><code>+++\n</code></pre>\n",
+			string(res[1].Content), "second content, code block not split")
+	}
+
+}
+
+func Test_ParseMulti_SplitOnHeading(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `Ignored preamble? No, kept.
+
+## Alpha
+
+    Order: 1
+
+~~~
+## Not a heading
+~~~
+
+## Beta
+
+### Not split
+
+Done.
+`
+	parser := frostedmd.New()
+	parser.Splitter = frostedmd.SplitOnHeading(2)
+	res, err := parser.ParseMulti([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	if assert.Equal(3, len(res), "three results") {
+		assert.Equal(map[string]interface{}{}, res[0].Meta, "preamble")
+		assert.Equal(map[string]interface{}{
+			"Title": "Alpha",
+			"Order": 1,
+		}, res[1].Meta, "alpha meta")
+		assert.Contains(string(res[1].Content), "## Not a heading",
+			"fenced heading kept")
+		assert.Equal(map[string]interface{}{
+			"Title": "Beta",
+		}, res[2].Meta, "beta meta")
+		assert.Contains(string(res[2].Content), "<h3>Not split</h3>",
+			"lower-level heading kept")
+	}
+
+}
+
+func Test_ParseMulti_Error(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# One\n\n+++\n\n# Two\n\n```yaml\nfoo: [\n```\n\n+++\n\nThree."
+
+	res, err := frostedmd.New().ParseMulti([]byte(input))
+
+	if assert.Error(err, "error returned") {
+		assert.Regexp("^Document 2: yaml", err.Error(), "error useful")
+	}
+	if assert.Equal(3, len(res), "all results returned") {
+		assert.Equal("One", res[0].Meta["Title"], "first meta")
+		assert.Nil(res[1].Meta, "broken meta")
+		assert.Equal("<p>Three.</p>\n", string(res[2].Content),
+			"third content")
+	}
+
+}
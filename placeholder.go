@@ -0,0 +1,192 @@
+// placeholder.go - placeholders for HTML fragments inserted in the source.
+//
+// Extensions such as shortcodes and wiki links produce HTML which must not
+// be processed as Markdown.  They replace their source text with a unique
+// alphanumeric placeholder, which the renderer passes through untouched,
+// and the placeholders are replaced with the HTML after rendering.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholders holds HTML fragments keyed by their placeholders.
+type placeholders struct {
+	prefix  string
+	outputs []string
+	re      *regexp.Regexp
+}
+
+// newPlaceholders returns an empty placeholders set for the input.
+func newPlaceholders(input []byte) *placeholders {
+
+	// The placeholder prefix must not occur in the input.
+	prefix := "fmdph"
+	for bytes.Contains(input, []byte(prefix)) {
+		prefix += "z"
+	}
+	return &placeholders{
+		prefix: prefix,
+		re:     regexp.MustCompile(`(<p>)?` + prefix + `(\d+)x(</p>\n?)?`),
+	}
+}
+
+// add adds the HTML fragment and returns its placeholder.
+func (ph *placeholders) add(html string) string {
+
+	ph.outputs = append(ph.outputs, html)
+	return fmt.Sprintf("%s%dx", ph.prefix, len(ph.outputs)-1)
+}
+
+// textRanges returns the byte ranges of the input which are not code, i.e.
+// excluding fenced and indented code blocks and code spans.
+func textRanges(input []byte) [][2]int {
+
+	ranges := [][2]int{}
+	add := func(start, end int) {
+		if end > start {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+	for _, b := range sourceBlocks(input) {
+		if b.kind == srcFenced || b.kind == srcIndented {
+			continue
+		}
+		pos := b.start
+		for i := b.start; i < b.end; i++ {
+			if input[i] != '`' {
+				continue
+			}
+			n := 1
+			for i+n < b.end && input[i+n] == '`' {
+				n++
+			}
+			span := closingBackticks(input[i+n:b.end], n)
+			if span < 0 {
+				i += n - 1
+				continue
+			}
+			add(pos, i)
+			i += n + span + n - 1
+			pos = i + 1
+		}
+		add(pos, b.end)
+	}
+	return ranges
+}
+
+// closingBackticks returns the index of the first run of exactly n
+// backticks in the input, or -1 if there is none.
+func closingBackticks(input []byte, n int) int {
+
+	for i := 0; i < len(input); i++ {
+		if input[i] != '`' {
+			continue
+		}
+		run := 1
+		for i+run < len(input) && input[i+run] == '`' {
+			run++
+		}
+		if run == n {
+			return i
+		}
+		i += run - 1
+	}
+	return -1
+}
+
+// replace replaces the placeholders in the rendered HTML with their
+// fragments.  A placeholder that is the only content of a paragraph replaces
+// the paragraph, as a block-level shortcode should.
+func (ph *placeholders) replace(html []byte) []byte {
+
+	return ph.re.ReplaceAllFunc(html, func(match []byte) []byte {
+		m := ph.re.FindSubmatch(match)
+		idx, _ := strconv.Atoi(string(m[2]))
+		if idx >= len(ph.outputs) {
+			return match
+		}
+		out := ph.replace([]byte(ph.outputs[idx]))
+		switch {
+		case len(m[1]) > 0 && len(m[3]) > 0:
+			if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+				out = append(out, '\n')
+			}
+			return out
+		case len(m[1]) > 0:
+			return append([]byte("<p>"), out...)
+		case len(m[3]) > 0:
+			return append(out, m[3]...)
+		}
+		return out
+	})
+}
+
+// replaceText replaces the placeholders in plain text with the plain text
+// of their fragments.
+func (ph *placeholders) replaceText(text string) string {
+
+	if !strings.Contains(text, ph.prefix) {
+		return text
+	}
+	return plainText(ph.replace([]byte(text)))
+}
+
+// apply replaces the placeholders throughout the result.
+func (ph *placeholders) apply(res *ParseResult) {
+
+	if len(ph.outputs) == 0 {
+		return
+	}
+	res.Content = ph.replace(res.Content)
+	for k, v := range res.Meta {
+		res.Meta[k] = ph.replaceValue(v)
+	}
+	for i := range res.Headings {
+		res.Headings[i].Text = ph.replaceText(res.Headings[i].Text)
+	}
+	for _, s := range res.Sections {
+		s.Heading = ph.replaceText(s.Heading)
+		s.HTML = string(ph.replace([]byte(s.HTML)))
+		s.Text = plainText([]byte(s.HTML))
+	}
+	for _, t := range res.Tables {
+		for i, cell := range t.Headers {
+			t.Headers[i] = newTableCell(ph.replace([]byte(cell.HTML)))
+		}
+		for _, row := range t.Rows {
+			for i, cell := range row {
+				row[i] = newTableCell(ph.replace([]byte(cell.HTML)))
+			}
+		}
+	}
+}
+
+// replaceValue replaces the placeholders in the strings of a Meta value,
+// such as a Title taken from a heading.
+func (ph *placeholders) replaceValue(v interface{}) interface{} {
+
+	switch v := v.(type) {
+	case string:
+		return ph.replaceText(v)
+	case []interface{}:
+		for i, item := range v {
+			v[i] = ph.replaceValue(item)
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = ph.replaceValue(item)
+		}
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			v[k] = ph.replaceValue(item)
+		}
+	}
+	return v
+}
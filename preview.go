@@ -0,0 +1,279 @@
+// preview.go - previewing a tree of Markdown files over HTTP.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PreviewEventsPath is the URL path of the live reload event stream served
+// by a Preview.
+const PreviewEventsPath = "/_fmd/events"
+
+// previewScript reloads the page when the Preview reports changes.
+const previewScript = `<script>
+new EventSource("` + PreviewEventsPath + `").addEventListener("reload",
+  function() { location.reload(); });
+</script>
+`
+
+// previewOverlay is the format of the error overlay, given the escaped
+// error message.
+const previewOverlay = `<div id="fmd-error" style="position: fixed; ` +
+	`top: 0; left: 0; right: 0; z-index: 9999; margin: 0; padding: 1em; ` +
+	`background: #fdd; color: #900; border-bottom: 2px solid #900; ` +
+	`font: 14px/1.4 Menlo, Consolas, monospace; white-space: pre-wrap;">` +
+	`%s</div>
+`
+
+// Preview is an http.Handler serving a tree of Markdown files as they would
+// be built by its Builder, for previewing them while they are written.
+//
+// Markdown files are rendered on every request, at their ".html" paths as
+// well as their own; directories without an index page, such as index.md,
+// get a generated index, and other files are served as-is.  Hidden files
+// are not served.
+//
+// Errors in a document, such as meta errors, are shown in an overlay on
+// the page as far as it could be rendered, rather than as a server error.
+// Every page has a script which reloads it when Notify is called, usually
+// by Watch, through a stream of server-sent events at PreviewEventsPath.
+type Preview struct {
+	FS      fs.FS
+	Builder *Builder
+
+	mu      sync.Mutex
+	clients map[chan []string]bool
+}
+
+// NewPreview returns a Preview of fsys with a new Builder.
+func NewPreview(fsys fs.FS) *Preview {
+	return &Preview{FS: fsys, Builder: NewBuilder()}
+}
+
+// ServeHTTP serves the request per the http.Handler interface.
+func (p *Preview) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.URL.Path == PreviewEventsPath {
+		p.serveEvents(w, r)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") && part != "." {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if info, err := fs.Stat(p.FS, name); err == nil && info.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		for _, ext := range CollectionExtensions {
+			if p.servePage(w, path.Join(name, "index"+ext)) {
+				return
+			}
+		}
+		p.serveIndex(w, r, name)
+		return
+	}
+
+	base := strings.TrimSuffix(name, path.Ext(name))
+	switch {
+	case hasExtension(name, CollectionExtensions):
+		if p.servePage(w, name) {
+			return
+		}
+	case strings.HasSuffix(name, ".html") || path.Ext(name) == "":
+		for _, ext := range CollectionExtensions {
+			if p.servePage(w, base+ext) {
+				return
+			}
+		}
+	}
+	http.FileServer(http.FS(p.FS)).ServeHTTP(w, r)
+}
+
+// servePage renders the named Markdown file, if it exists, returning false
+// if it does not.
+func (p *Preview) servePage(w http.ResponseWriter, name string) bool {
+
+	if _, err := fs.Stat(p.FS, name); err != nil {
+		return false
+	}
+	res, err := p.Builder.Parser.ParseFile(p.FS, name)
+	var page []byte
+	if res != nil {
+		var renderErr error
+		page, renderErr = p.Builder.Render(res)
+		if err == nil {
+			err = renderErr
+		}
+	}
+	if page == nil {
+		page = p.errorPage()
+	}
+	if err != nil {
+		be := BuildError{Path: name, Err: err}
+		page = addOverlay(page, be.Position()+": "+err.Error())
+	}
+	p.writePage(w, page)
+	return true
+}
+
+// serveIndex serves the generated index of a directory.
+func (p *Preview) serveIndex(w http.ResponseWriter, r *http.Request, dir string) {
+
+	entries, err := fs.ReadDir(p.FS, dir)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data := newIndexData(dir)
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		switch {
+		case strings.HasPrefix(entry.Name(), "."):
+		case entry.IsDir():
+			data.Dirs = append(data.Dirs, IndexEntry{
+				Title: entry.Name(),
+				URL:   entry.Name() + "/",
+			})
+		case hasExtension(name, CollectionExtensions):
+			res, _ := p.Builder.Parser.ParseFile(p.FS, name)
+			if res != nil {
+				data.Pages = append(data.Pages, newPageEntry(res))
+			}
+		}
+	}
+	page, err := p.Builder.RenderIndex(data)
+	if err != nil {
+		page = addOverlay(p.errorPage(), err.Error())
+	}
+	p.writePage(w, page)
+}
+
+// errorPage returns an empty page, for errors that prevented rendering.
+func (p *Preview) errorPage() []byte {
+
+	res := &ParseResult{Meta: map[string]interface{}{"Title": "Error"}}
+	p.Builder.Parser.wrapDocument(res)
+	return res.Content
+}
+
+// writePage writes the page with the live reload script added before the
+// end of its body, never to be cached.
+func (p *Preview) writePage(w http.ResponseWriter, page []byte) {
+
+	if i := bytes.LastIndex(page, []byte("</body>")); i >= 0 {
+		page = append(page[:i:i], append([]byte(previewScript),
+			page[i:]...)...)
+	} else {
+		page = append(page, previewScript...)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(page)
+}
+
+// addOverlay adds the error overlay for msg at the start of the page's body.
+func addOverlay(page []byte, msg string) []byte {
+
+	overlay := []byte(fmt.Sprintf(previewOverlay, html.EscapeString(msg)))
+	i := bytes.Index(page, []byte("<body>"))
+	if i < 0 {
+		return append(overlay, page...)
+	}
+	i += len("<body>\n")
+	if i > len(page) {
+		i = len(page)
+	}
+	return append(page[:i:i], append(overlay, page[i:]...)...)
+}
+
+// serveEvents streams a "reload" event, with the list of changed files as
+// its data, every time Notify is called.
+func (p *Preview) serveEvents(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported.", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan []string, 1)
+	p.mu.Lock()
+	if p.clients == nil {
+		p.clients = map[chan []string]bool{}
+	}
+	p.clients[ch] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.clients, ch)
+		p.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case changed := <-ch:
+			data, _ := json.Marshal(changed)
+			fmt.Fprintf(w, "event: reload\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// Notify sends a reload event listing the changed files to every page
+// being previewed.  Pages that have not yet received the last event are
+// skipped, as they will reload anyway.
+func (p *Preview) Notify(changed []string) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ch := range p.clients {
+		select {
+		case ch <- changed:
+		default:
+		}
+	}
+}
+
+// Watch watches the FS for changes until the context is done, calling
+// Notify for each batch.  Errors in watching are passed to the onError
+// function if it is not nil.
+func (p *Preview) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+
+	watcher := NewWatcher(p.FS)
+	watcher.Interval = interval
+	for ev := range watcher.Watch(ctx) {
+		if ev.Err != nil {
+			if onError != nil {
+				onError(ev.Err)
+			}
+			continue
+		}
+		p.Notify(ev.Changed)
+	}
+}
@@ -0,0 +1,177 @@
+// preview_test.go
+
+package frostedmd_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+var previewFS = fstest.MapFS{
+	"index.md":        {Data: []byte("# Home\n\nSee [a](docs/a.md).\n")},
+	"docs/a.md":       {Data: []byte("# Page A\n\nHello.\n")},
+	"docs/b.markdown": {Data: []byte("    Title: Bee\n\nBuzz.\n")},
+	"docs/bad.md":     {Data: []byte("# Bad\n\n    x: [y\n\nStill here.\n")},
+	"img/logo.png":    {Data: []byte("PNG")},
+	".git/config":     {Data: []byte("secret")},
+}
+
+func previewGet(t *testing.T, h http.Handler, url string) *httptest.ResponseRecorder {
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	return rec
+}
+
+func Test_Preview_Pages(t *testing.T) {
+
+	assert := assert.New(t)
+
+	preview := frostedmd.NewPreview(previewFS)
+
+	for _, url := range []string{"/", "/index.html", "/index.md", "/index"} {
+		rec := previewGet(t, preview, url)
+		body := rec.Body.String()
+		assert.Equal(200, rec.Code, "status for %s", url)
+		assert.Equal("text/html; charset=utf-8",
+			rec.Header().Get("Content-Type"), "type for %s", url)
+		assert.Contains(body, "<title>Home</title>", "document for %s", url)
+		assert.Contains(body, `href="docs/a.html"`, "link rewritten for %s",
+			url)
+		assert.Contains(body, frostedmd.PreviewEventsPath+`")`,
+			"reload script for %s", url)
+		assert.True(strings.Index(body, "<script>") <
+			strings.Index(body, "</body>"), "script in body for %s", url)
+		assert.NotContains(body, "fmd-error", "no overlay for %s", url)
+	}
+
+	rec := previewGet(t, preview, "/docs/b.html")
+	assert.Contains(rec.Body.String(), "<title>Bee</title>", "markdown ext")
+
+	other := frostedmd.NewPreview(fstest.MapFS{
+		"guide/index.markdown": {Data: []byte("# Guide Home\n")},
+	})
+	rec = previewGet(t, other, "/guide/")
+	assert.Contains(rec.Body.String(), "<title>Guide Home</title>",
+		"index.markdown served for directory")
+
+	rec = previewGet(t, preview, "/img/logo.png")
+	assert.Equal("PNG", rec.Body.String(), "asset served")
+
+	for _, url := range []string{"/.git/config", "/nope.html", "/img/x"} {
+		assert.Equal(404, previewGet(t, preview, url).Code,
+			"not found: %s", url)
+	}
+
+	rec = previewGet(t, preview, "/docs")
+	assert.Equal(301, rec.Code, "directory redirected")
+	assert.Equal("/docs/", rec.Header().Get("Location"), "to slash")
+
+}
+
+func Test_Preview_Index(t *testing.T) {
+
+	assert := assert.New(t)
+
+	rec := previewGet(t, frostedmd.NewPreview(previewFS), "/docs/")
+	body := rec.Body.String()
+	assert.Equal(200, rec.Code, "status")
+	assert.Contains(body, `<a href="a.html">Page A</a>`, "page listed")
+	assert.Contains(body, `<a href="b.html">Bee</a>`, "title from meta")
+	assert.Contains(body, `<a href="bad.html">bad.md</a>`, "broken page listed")
+
+	rec = previewGet(t, frostedmd.NewPreview(fstest.MapFS{
+		"sub/x.md": {Data: []byte("X")},
+	}), "/")
+	assert.Contains(rec.Body.String(), `<a href="sub/">sub</a>`,
+		"subdirectory listed")
+
+}
+
+func Test_Preview_ErrorOverlay(t *testing.T) {
+
+	assert := assert.New(t)
+
+	preview := frostedmd.NewPreview(previewFS)
+	rec := previewGet(t, preview, "/docs/bad.html")
+	body := rec.Body.String()
+	assert.Equal(200, rec.Code, "not a server error")
+	assert.Regexp(`<body>\n<div id="fmd-error"[^>]*>docs/bad.md:3: yaml: `,
+		body, "overlay with position")
+	assert.Contains(body, "Still here.", "content still rendered")
+
+	// Template errors leave nothing to render but the overlay.
+	preview.Builder.Layout = nil
+	preview.Builder.Parser.ExpandTemplates = true
+	preview.FS = fstest.MapFS{
+		"t.md": {Data: []byte("# T\n\n{{ .Nope }\n")},
+	}
+	body = previewGet(t, preview, "/t.html").Body.String()
+	assert.Contains(body, "t.md: Template error on line 3", "template error")
+	assert.Contains(body, "</html>", "still a document")
+
+}
+
+func Test_Preview_Events(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.md"), []byte("A"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+	preview := frostedmd.NewPreview(os.DirFS(dir))
+	server := httptest.NewServer(preview)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET",
+		server.URL+frostedmd.PreviewEventsPath, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"),
+		"event stream")
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	assert.Equal(": connected", <-lines, "connected")
+	<-lines
+
+	go preview.Watch(ctx, 5*time.Millisecond, nil)
+	time.Sleep(20 * time.Millisecond) // let the watcher see the first state
+	if err := os.WriteFile(filepath.Join(dir, "a.md"), []byte("AA"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-lines:
+		assert.Equal("event: reload", line, "reload event")
+		assert.Equal(`data: ["a.md"]`, <-lines, "changed files")
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+
+}
@@ -2,6 +2,9 @@ package frostedmd
 
 import (
 	"bytes"
+	"html"
+	"regexp"
+	"strings"
 
 	// Third-party:
 	"gopkg.in/russross/blackfriday.v1"
@@ -33,7 +36,67 @@ type fmdRenderer struct {
 	metaBytes   []byte
 	metaLang    string
 	headerTitle string
+	dataBlocks  []dataBlock
+	headings    []Heading
 	bfRenderer  blackfriday.Renderer // Blackfriday's renderer
+
+	// Table extraction, if wanted:
+	extractTables bool
+	tables        []*Table
+	tableHeaders  []TableCell
+	tableRows     [][]TableCell
+	tableRow      []TableCell
+	headerRow     bool
+
+	// Section splitting, if wanted:
+	sectionLevel int
+	sectionMarks []sectionMark
+	mainOut      *bytes.Buffer
+}
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// blockTagRegexp matches the tags which separate words in plain text.
+var blockTagRegexp = regexp.MustCompile(`^</?(p|div|br|hr|h[1-6]|li|dt|dd|` +
+	`t[dhr]|table|thead|tbody|pre|blockquote|figure)\b`)
+
+// plainText converts an HTML fragment to plain text, as would be suitable
+// for searching or for display in a non-HTML context: tags are removed,
+// entities are unescaped, and whitespace is collapsed.
+func plainText(fragment []byte) string {
+
+	text := htmlTagRegexp.ReplaceAllStringFunc(string(fragment),
+		func(tag string) string {
+			if blockTagRegexp.MatchString(tag) {
+				return " "
+			}
+			return ""
+		})
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// A dataBlock is a named block of data to be decoded into the Meta.
+type dataBlock struct {
+	name string
+	lang string
+	text []byte
+}
+
+// dataBlockInfo parses a code block info string such as "yaml data:foo",
+// returning the name and the language of the block.  If the info string
+// has no (nonempty) data name then the name returned is the empty string.
+func dataBlockInfo(info string) (name, lang string) {
+
+	for _, field := range strings.Fields(info) {
+		if strings.HasPrefix(field, "data:") {
+			if name == "" {
+				name = strings.TrimPrefix(field, "data:")
+			}
+		} else if lang == "" {
+			lang = field
+		}
+	}
+	return name, lang
 }
 
 // This is a bit goofy but it helps us support meta-at-end, which is useful
@@ -63,6 +126,16 @@ func (r *fmdRenderer) incrementBlocks(out *bytes.Buffer) {
 // block-level callbacks
 func (r *fmdRenderer) BlockCode(out *bytes.Buffer, text []byte, lang string) {
 
+	// Named data blocks are always removed, and never count as blocks.
+	if name, dataLang := dataBlockInfo(lang); name != "" {
+		r.dataBlocks = append(r.dataBlocks, dataBlock{
+			name: name,
+			lang: dataLang,
+			text: text,
+		})
+		return
+	}
+
 	// If we are looking for the meta block at the end, any block could be it.
 	if r.metaAtEnd {
 		r.haveMeta = true
@@ -102,7 +175,32 @@ func (r *fmdRenderer) Header(out *bytes.Buffer, text func() bool, level int, id
 	}
 
 	r.incrementBlocks(out)
-	r.bfRenderer.Header(out, text, level, id)
+
+	// We need to know the heading's text, and where it begins and ends.
+	var inner []byte
+	wrapped := func() bool {
+		marker := out.Len()
+		ok := text()
+		inner = append(inner, out.Bytes()[marker:]...)
+		return ok
+	}
+	start := out.Len()
+	r.bfRenderer.Header(out, wrapped, level, id)
+	heading := Heading{
+		Level: level,
+		ID:    headerID(out.Bytes()[start:]),
+		Text:  plainText(inner),
+	}
+	r.headings = append(r.headings, heading)
+
+	// Only top-level headings can start sections.
+	if r.sectionLevel > 0 && level <= r.sectionLevel && out == r.mainOut {
+		r.sectionMarks = append(r.sectionMarks, sectionMark{
+			start:     start,
+			bodyStart: out.Len(),
+			heading:   heading,
+		})
+	}
 }
 func (r *fmdRenderer) HRule(out *bytes.Buffer) {
 	r.incrementBlocks(out)
@@ -123,18 +221,46 @@ func (r *fmdRenderer) Paragraph(out *bytes.Buffer, text func() bool) {
 func (r *fmdRenderer) Table(out *bytes.Buffer, header []byte, body []byte, columnData []int) {
 	r.incrementBlocks(out)
 	r.bfRenderer.Table(out, header, body, columnData)
+
+	// Blackfriday renders all the cells and rows before the table itself,
+	// so we have everything we need by now.
+	if r.extractTables {
+		r.tables = append(r.tables, &Table{
+			Headers: r.tableHeaders,
+			Rows:    r.tableRows,
+			Align:   tableAlign(columnData),
+		})
+		r.tableHeaders = nil
+		r.tableRows = nil
+	}
 }
 func (r *fmdRenderer) TableRow(out *bytes.Buffer, text []byte) {
 	r.incrementBlocks(out)
 	r.bfRenderer.TableRow(out, text)
+	if r.extractTables {
+		if r.headerRow {
+			r.tableHeaders = r.tableRow
+		} else {
+			r.tableRows = append(r.tableRows, r.tableRow)
+		}
+		r.tableRow = nil
+		r.headerRow = false
+	}
 }
 func (r *fmdRenderer) TableHeaderCell(out *bytes.Buffer, text []byte, flags int) {
 	r.incrementBlocks(out)
 	r.bfRenderer.TableHeaderCell(out, text, flags)
+	if r.extractTables {
+		r.tableRow = append(r.tableRow, newTableCell(text))
+		r.headerRow = true
+	}
 }
 func (r *fmdRenderer) TableCell(out *bytes.Buffer, text []byte, flags int) {
 	r.incrementBlocks(out)
 	r.bfRenderer.TableCell(out, text, flags)
+	if r.extractTables {
+		r.tableRow = append(r.tableRow, newTableCell(text))
+	}
 }
 func (r *fmdRenderer) Footnotes(out *bytes.Buffer, text func() bool) {
 	r.incrementBlocks(out)
@@ -194,6 +320,7 @@ func (r *fmdRenderer) NormalText(out *bytes.Buffer, text []byte) {
 
 // Header and footer
 func (r *fmdRenderer) DocumentHeader(out *bytes.Buffer) {
+	r.mainOut = out // everything else is nested
 	r.bfRenderer.DocumentHeader(out)
 }
 func (r *fmdRenderer) DocumentFooter(out *bytes.Buffer) {
@@ -0,0 +1,85 @@
+// sections.go - splitting documents into sections by heading.
+
+package frostedmd
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// Section describes a part of a document beginning with a heading, as
+// collected if the Parser's SectionLevel is set.  Every top-level heading of
+// that level or lower (i.e. more important) starts a new Section; headings
+// within e.g. block quotes are ignored.  Any content preceding the first such
+// heading is included as a Section with an empty Heading and a Level of zero.
+// The HTML and Text of a section do not include the heading itself.
+type Section struct {
+	Heading string `json:"heading"`
+	ID      string `json:"id"`
+	Level   int    `json:"level"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// Heading describes a heading in a document, as collected in the Headings
+// of every ParseResult.
+type Heading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// sectionMark records the position of a section heading in the output.
+type sectionMark struct {
+	start     int // start of the heading
+	bodyStart int // end of the heading, thus start of the section body
+	heading   Heading
+}
+
+var headerIDRegexp = regexp.MustCompile(`^\s*<h\d[^>]*\sid="([^"]*)"`)
+
+// headerID returns the id attribute of a rendered heading, if any.  We take
+// it from the HTML because the renderer may have modified the original.
+func headerID(rendered []byte) string {
+
+	m := headerIDRegexp.FindSubmatch(rendered)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+// buildSections splits the content according to the marks.
+func buildSections(content []byte, marks []sectionMark) []*Section {
+
+	sections := []*Section{}
+	add := func(s *Section, body []byte) {
+		body = bytes.TrimSpace(body)
+		if s.Level == 0 && len(body) == 0 {
+			return
+		}
+		s.HTML = string(body)
+		s.Text = plainText(body)
+		sections = append(sections, s)
+	}
+
+	end := len(content)
+	if len(marks) > 0 {
+		end = marks[0].start
+	}
+	add(&Section{}, content[:end])
+
+	for i, mark := range marks {
+		end = len(content)
+		if i+1 < len(marks) {
+			end = marks[i+1].start
+		}
+		add(&Section{
+			Heading: mark.heading.Text,
+			ID:      mark.heading.ID,
+			Level:   mark.heading.Level,
+		}, content[mark.bodyStart:end])
+	}
+
+	return sections
+}
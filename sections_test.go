@@ -0,0 +1,102 @@
+// sections_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/russross/blackfriday.v1"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Parse_Sections(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# The Doc
+
+    Tags: [a, b]
+
+Preamble here.
+
+## First *Part* {#first}
+
+One.
+
+> ## Not a section
+
+### Sub-part
+
+Still one.
+
+## Second
+
+Two.
+`
+	exp := []*frostedmd.Section{
+		{
+			Heading: "The Doc",
+			Level:   1,
+			HTML:    "<p>Preamble here.</p>",
+			Text:    "Preamble here.",
+		},
+		{
+			Heading: "First Part",
+			ID:      "first",
+			Level:   2,
+			HTML: "<p>One.</p>\n\n<blockquote>\n<h2>Not a section</h2>\n" +
+				"</blockquote>\n\n<h3>Sub-part</h3>\n\n<p>Still one.</p>",
+			Text: "One. Not a section Sub-part Still one.",
+		},
+		{
+			Heading: "Second",
+			Level:   2,
+			HTML:    "<p>Two.</p>",
+			Text:    "Two.",
+		},
+	}
+
+	parser := frostedmd.New()
+	parser.SectionLevel = 2
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(exp, res.Sections, "sections as expected")
+
+}
+
+func Test_Parse_Sections_AutoIDs(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "Intro.\n\n# Alpha\n\nA.\n\n# Alpha\n\nB.\n"
+	exp := []*frostedmd.Section{
+		{HTML: "<p>Intro.</p>", Text: "Intro."},
+		{Heading: "Alpha", ID: "alpha", Level: 1, HTML: "<p>A.</p>",
+			Text: "A."},
+		{Heading: "Alpha", ID: "alpha-1", Level: 1, HTML: "<p>B.</p>",
+			Text: "B."},
+	}
+
+	parser := frostedmd.New()
+	parser.MarkdownExtensions |= blackfriday.EXTENSION_AUTO_HEADER_IDS
+	parser.SectionLevel = 1
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(exp, res.Sections, "sections as expected")
+
+}
+
+func Test_Parse_Sections_Off(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res, err := frostedmd.New().Parse([]byte("# One\n\n## Two\n"))
+
+	assert.Nil(err, "no error returned")
+	assert.Nil(res.Sections, "no sections by default")
+
+}
@@ -0,0 +1,311 @@
+// shortcode.go - shortcodes for reusable content widgets.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shortcode renders a shortcode invocation as HTML.  For paired shortcodes
+// such as {{< note >}}...{{< /note >}} the inner content is the raw source
+// between the tags; for standalone shortcodes it is the empty string.
+//
+// Nested shortcodes within the inner content have already been expanded,
+// and appear as alphanumeric placeholders which are replaced in the final
+// HTML.  If the inner content is rendered as Markdown, the placeholders
+// are thus handled normally.
+type Shortcode func(args ShortcodeArgs, inner string) (string, error)
+
+// ShortcodeArgs holds the arguments of a shortcode invocation.  Arguments
+// of the form key=value are Named, all others Positional.  Values may be
+// double-quoted, with Go escapes, or backquoted.
+type ShortcodeArgs struct {
+	Positional []string
+	Named      map[string]string
+}
+
+// Get returns the named argument if present, else the positional argument
+// at index pos, else the empty string.
+func (a ShortcodeArgs) Get(name string, pos int) string {
+
+	if v, ok := a.Named[name]; ok {
+		return v
+	}
+	if pos >= 0 && pos < len(a.Positional) {
+		return a.Positional[pos]
+	}
+	return ""
+}
+
+// ShortcodeError describes an error encountered while expanding a shortcode,
+// with the line and column (both counted from 1) of its opening tag.
+type ShortcodeError struct {
+	Name string
+	Line int
+	Col  int
+	Err  error
+}
+
+// Error stringifies the error per the error interface.
+func (e ShortcodeError) Error() string {
+	return fmt.Sprintf("Shortcode error on line %d, column %d: %s: %s",
+		e.Line, e.Col, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ShortcodeError) Unwrap() error {
+	return e.Err
+}
+
+// ShortcodeErrors collects all errors encountered while expanding the
+// shortcodes in a source, in order of appearance.
+type ShortcodeErrors []ShortcodeError
+
+// Error stringifies the error per the error interface, with one line per
+// shortcode error.
+func (e ShortcodeErrors) Error() string {
+
+	msgs := make([]string, len(e))
+	for i, se := range e {
+		msgs[i] = se.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// shortcodeTag is a shortcode tag found in a source.
+type shortcodeTag struct {
+	start   int
+	end     int
+	name    string
+	args    string
+	closing bool
+	closer  *shortcodeTag // for paired opening tags
+}
+
+var shortcodeNameRegexp = regexp.MustCompile(`^\s*(/?)\s*([A-Za-z][\w-]*)`)
+
+// expandShortcodes replaces the shortcodes in the input with placeholders,
+// returning the resulting source.  Tags within code blocks and code spans
+// are never expanded.  Shortcodes that fail are left in the source as-is,
+// and their errors are returned as ShortcodeErrors, positioned in the
+// original source per sm.
+func (p *Parser) expandShortcodes(input []byte, ph *placeholders, sm *sourceMap) (*sourceBuffer, error) {
+
+	tags := findShortcodeTags(input)
+	var errs ShortcodeErrors
+	fail := func(tag *shortcodeTag, err error) {
+		line, col := sm.position(tag.start)
+		errs = append(errs, ShortcodeError{
+			Name: tag.name,
+			Line: line,
+			Col:  col,
+			Err:  err,
+		})
+	}
+
+	// Pair the tags, innermost first.
+	open := []*shortcodeTag{}
+	for _, tag := range tags {
+		if !tag.closing {
+			open = append(open, tag)
+			continue
+		}
+		matched := false
+		for i := len(open) - 1; i >= 0; i-- {
+			if open[i].name == tag.name {
+				open[i].closer = tag
+				open = open[:i]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail(tag, errors.New("Closing tag without opening tag."))
+		}
+	}
+
+	var expand func(start, end int, tags []*shortcodeTag) *sourceBuffer
+	expand = func(start, end int, tags []*shortcodeTag) *sourceBuffer {
+		out := &sourceBuffer{}
+		pos := start
+		for i := 0; i < len(tags); i++ {
+			tag := tags[i]
+			if tag.start < pos {
+				continue // within a paired shortcode already handled
+			}
+			out.copy(input, pos, tag.start)
+			pos = tag.end
+			if tag.closing {
+				out.copy(input, tag.start, tag.end)
+				continue
+			}
+			inner := ""
+			if tag.closer != nil {
+				j := i + 1
+				for j < len(tags) && tags[j] != tag.closer {
+					j++
+				}
+				inner = expand(tag.end, tag.closer.start,
+					tags[i+1:j]).String()
+				pos = tag.closer.end
+			}
+			html, err := p.runShortcode(tag, inner)
+			if err != nil {
+				fail(tag, err)
+				out.copy(input, tag.start, pos)
+				continue
+			}
+			out.insert([]byte(ph.add(html)), tag.start)
+		}
+		out.copy(input, pos, end)
+		return out
+	}
+
+	output := expand(0, len(input), tags)
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool {
+			if errs[i].Line == errs[j].Line {
+				return errs[i].Col < errs[j].Col
+			}
+			return errs[i].Line < errs[j].Line
+		})
+		return output, errs
+	}
+	return output, nil
+}
+
+// runShortcode runs the registered shortcode for the tag.
+func (p *Parser) runShortcode(tag *shortcodeTag, inner string) (string, error) {
+
+	fn := p.Shortcodes[tag.name]
+	if fn == nil {
+		return "", errors.New("Unknown shortcode.")
+	}
+	args, err := parseShortcodeArgs(tag.args)
+	if err != nil {
+		return "", err
+	}
+	return fn(args, inner)
+}
+
+// findShortcodeTags returns all shortcode tags in the input, outside of
+// code blocks and code spans, in order.
+func findShortcodeTags(input []byte) []*shortcodeTag {
+
+	tags := []*shortcodeTag{}
+	for _, r := range textRanges(input) {
+		pos := r[0]
+		for pos < r[1] {
+			idx := bytes.Index(input[pos:r[1]], []byte("{{<"))
+			if idx < 0 {
+				break
+			}
+			start := pos + idx
+			tag := scanShortcodeTag(input[:r[1]], start)
+			if tag == nil {
+				pos = start + 3
+				continue
+			}
+			tags = append(tags, tag)
+			pos = tag.end
+		}
+	}
+	return tags
+}
+
+// scanShortcodeTag scans a shortcode tag beginning at start, respecting
+// quoted arguments.  It returns nil if there is no valid tag.
+func scanShortcodeTag(input []byte, start int) *shortcodeTag {
+
+	body := start + 3
+	var quote byte
+	for i := body; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case bytes.HasPrefix(input[i:], []byte(">}}")):
+			m := shortcodeNameRegexp.FindSubmatchIndex(input[body:i])
+			if m == nil {
+				return nil
+			}
+			return &shortcodeTag{
+				start:   start,
+				end:     i + 3,
+				closing: m[3] > m[2],
+				name:    string(input[body+m[4] : body+m[5]]),
+				args:    string(input[body+m[1] : i]),
+			}
+		}
+	}
+	return nil
+}
+
+// parseShortcodeArgs parses the argument string of a shortcode tag.
+func parseShortcodeArgs(s string) (ShortcodeArgs, error) {
+
+	args := ShortcodeArgs{Named: map[string]string{}}
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return args, nil
+		}
+		name := ""
+		if eq := strings.IndexByte(s, '='); eq > 0 &&
+			!strings.ContainsAny(s[:eq], " \t\n\"`") {
+			name = s[:eq]
+			s = s[eq+1:]
+		}
+		var val string
+		switch {
+		case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "`"):
+			quoted := quotedPrefix(s)
+			var err error
+			if val, err = strconv.Unquote(quoted); err != nil {
+				return args, errors.New("Bad quoted argument: " + s)
+			}
+			s = s[len(quoted):]
+		default:
+			end := strings.IndexAny(s, " \t\n")
+			if end < 0 {
+				end = len(s)
+			}
+			val = s[:end]
+			s = s[end:]
+		}
+		if name != "" {
+			args.Named[name] = val
+		} else {
+			args.Positional = append(args.Positional, val)
+		}
+	}
+}
+
+// quotedPrefix returns the double- or back-quoted string at the start of s,
+// up to the closing quote, or all of s if there is none.
+func quotedPrefix(s string) string {
+
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return s[:i+1]
+		}
+	}
+	return s
+}
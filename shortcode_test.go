@@ -0,0 +1,148 @@
+// shortcode_test.go
+
+package frostedmd_test
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func testShortcodes() map[string]frostedmd.Shortcode {
+
+	return map[string]frostedmd.Shortcode{
+		"youtube": func(args frostedmd.ShortcodeArgs, inner string) (string, error) {
+			id := args.Get("id", 0)
+			if id == "" {
+				return "", errors.New("No id.")
+			}
+			return `<iframe src="https://www.youtube.com/embed/` +
+				html.EscapeString(id) + `"></iframe>`, nil
+		},
+		"note": func(args frostedmd.ShortcodeArgs, inner string) (string, error) {
+			res, err := frostedmd.MarkdownCommon([]byte(inner))
+			if err != nil {
+				return "", err
+			}
+			return `<div class="note">` + strings.TrimSpace(
+				string(res.Content)) + `</div>`, nil
+		},
+		"figure": func(args frostedmd.ShortcodeArgs, inner string) (string, error) {
+			return fmt.Sprintf(`<figure><img src="%s" alt="%s" /></figure>`,
+				html.EscapeString(args.Named["src"]),
+				html.EscapeString(args.Named["alt"])), nil
+		},
+		"kbd": func(args frostedmd.ShortcodeArgs, inner string) (string, error) {
+			return "<kbd>" + html.EscapeString(
+				strings.Join(args.Positional, "+")) + "</kbd>", nil
+		},
+	}
+}
+
+func Test_Parse_Shortcodes(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Widgets\n\n" +
+		"{{< youtube abc123 >}}\n\n" +
+		"{{< note >}}\nBe **careful**, press {{< kbd Ctrl C >}}.\n" +
+		"{{< /note >}}\n\n" +
+		`{{< figure src="/img/a b.png" alt="A \"quoted\" >}} alt" >}}` +
+		"\n\n" +
+		"Press {{< kbd Ctrl Alt Del >}} but not " +
+		"`{{< kbd Ctrl Q >}}` or ``{{< kbd ` >}}``.\n\n" +
+		"```\n{{< youtube nope >}}\n```\n\n" +
+		"    {{< youtube nope >}}\n"
+
+	expContent := `<h1>Widgets</h1>
+
+<iframe src="https://www.youtube.com/embed/abc123"></iframe>
+
+<div class="note"><p>Be <strong>careful</strong>, press <kbd>Ctrl+C</kbd>.</p></div>
+
+<figure><img src="/img/a b.png" alt="A &#34;quoted&#34; &gt;}} alt" /></figure>
+
+<p>Press <kbd>Ctrl+Alt+Del</kbd> but not <code>{{&lt; kbd Ctrl Q &gt;}}</code> or <code>{{&lt; kbd ` + "`" + ` &gt;}}</code>.</p>
+
+<pre><code>{{&lt; youtube nope &gt;}}
+</code></pre>
+
+<pre><code>{{&lt; youtube nope &gt;}}
+</code></pre>
+`
+
+	parser := frostedmd.New()
+	parser.Shortcodes = testShortcodes()
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(map[string]interface{}{"Title": "Widgets"}, res.Meta,
+		"meta as expected")
+	assert.Equal(expContent, string(res.Content), "content as expected")
+
+}
+
+func Test_Parse_Shortcodes_Sections(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Keys {{< kbd Esc >}}\n\nPress {{< kbd Esc >}}.\n"
+
+	parser := frostedmd.New()
+	parser.Shortcodes = testShortcodes()
+	parser.SectionLevel = 1
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal("Keys Esc", res.Headings[0].Text, "heading text replaced")
+	assert.Equal("Keys Esc", res.Meta["Title"], "title replaced")
+	if assert.Equal(1, len(res.Sections), "one section") {
+		assert.Equal("Keys Esc", res.Sections[0].Heading, "section heading")
+		assert.Equal("Press Esc.", res.Sections[0].Text, "section text")
+	}
+
+}
+
+func Test_Parse_Shortcodes_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# Errors\n\n" +
+		"{{< youtube >}}\n\n" +
+		"Some {{< nope >}} and {{< /note >}}.\n\n" +
+		"{{< youtube ok >}}\n"
+
+	parser := frostedmd.New()
+	parser.Shortcodes = testShortcodes()
+	res, err := parser.Parse([]byte(input))
+
+	if assert.Error(err, "error returned") {
+		assert.Equal("Shortcode error on line 3, column 1: youtube: No id.\n"+
+			"Shortcode error on line 5, column 6: nope: Unknown shortcode.\n"+
+			"Shortcode error on line 5, column 23: note: "+
+			"Closing tag without opening tag.",
+			err.Error(), "error lists all shortcodes in order")
+		var se frostedmd.ShortcodeErrors
+		if assert.True(errors.As(err, &se), "ShortcodeErrors returned") {
+			assert.Equal(3, len(se), "three errors")
+		}
+	}
+	assert.Equal(map[string]interface{}{"Title": "Errors"}, res.Meta,
+		"meta still returned")
+	assert.Contains(string(res.Content),
+		`<iframe src="https://www.youtube.com/embed/ok"></iframe>`,
+		"good shortcode expanded")
+	assert.Contains(string(res.Content), "<p>{{&lt; youtube &gt;}}</p>",
+		"bad shortcode left as-is")
+
+	_, err = parser.Parse([]byte(`{{< youtube "a\qb" >}}`))
+	assert.EqualError(err, `Shortcode error on line 1, column 1: youtube: `+
+		`Bad quoted argument: "a\qb"`, "bad escape in quoted argument")
+
+}
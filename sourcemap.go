@@ -0,0 +1,135 @@
+// sourcemap.go - mapping expanded sources back to the original input.
+//
+// Includes, wiki links and shortcodes are expanded before the source is
+// rendered, so positions found in the expanded source must be mapped back
+// in order to report errors against the input as the user wrote it.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"sort"
+)
+
+// sourceSegment maps the output from out onwards to the input at in.  If
+// the output was copied from the input, offsets advance together; if it
+// replaced something, e.g. an include directive, all of it maps to in.
+type sourceSegment struct {
+	out    int
+	in     int
+	copied bool
+}
+
+// sourceBuffer is a buffer which records where its content came from.
+type sourceBuffer struct {
+	bytes.Buffer
+	segments []sourceSegment
+}
+
+// copy writes input[start:end] to the buffer.
+func (b *sourceBuffer) copy(input []byte, start, end int) {
+
+	if end <= start {
+		return
+	}
+	b.segments = append(b.segments, sourceSegment{b.Len(), start, true})
+	b.Write(input[start:end])
+}
+
+// insert writes text to the buffer in place of the input at pos.
+func (b *sourceBuffer) insert(text []byte, pos int) {
+
+	if len(text) == 0 {
+		return
+	}
+	b.segments = append(b.segments, sourceSegment{b.Len(), pos, false})
+	b.Write(text)
+}
+
+// inputOffset returns the input offset for the output offset pos.
+func (b *sourceBuffer) inputOffset(pos int) int {
+
+	i := sort.Search(len(b.segments), func(i int) bool {
+		return b.segments[i].out > pos
+	}) - 1
+	if i < 0 {
+		return pos
+	}
+	seg := b.segments[i]
+	if !seg.copied {
+		return seg.in
+	}
+	return seg.in + pos - seg.out
+}
+
+// sourceMap maps positions in the current stage of an expanded source to
+// positions in the original input.
+type sourceMap struct {
+	input   []byte
+	current []byte
+	stages  []*sourceBuffer
+}
+
+// newSourceMap returns a sourceMap for the unexpanded input.
+func newSourceMap(input []byte) *sourceMap {
+	return &sourceMap{input: input, current: input}
+}
+
+// push adds an expansion stage, returning its output as the current source.
+func (sm *sourceMap) push(b *sourceBuffer) []byte {
+
+	sm.stages = append(sm.stages, b)
+	sm.current = b.Bytes()
+	return sm.current
+}
+
+// position returns the line and column, both counted from 1, in the input
+// of the offset pos in the current source.
+func (sm *sourceMap) position(pos int) (int, int) {
+
+	for i := len(sm.stages) - 1; i >= 0; i-- {
+		pos = sm.stages[i].inputOffset(pos)
+	}
+	if pos > len(sm.input) {
+		pos = len(sm.input)
+	}
+	line := bytes.Count(sm.input[:pos], []byte("\n")) + 1
+	col := pos - bytes.LastIndexByte(sm.input[:pos], '\n')
+	return line, col
+}
+
+// line returns the line in the input of the given line in the current
+// source.  Zero, meaning no line is known, is returned as-is.
+func (sm *sourceMap) line(line int) int {
+
+	if line <= 0 || len(sm.stages) == 0 {
+		return line
+	}
+	pos := 0
+	for n := 1; n < line; n++ {
+		idx := bytes.IndexByte(sm.current[pos:], '\n')
+		if idx < 0 {
+			pos = len(sm.current)
+			break
+		}
+		pos += idx + 1
+	}
+	line, _ = sm.position(pos)
+	return line
+}
+
+// mapError returns err with its line mapped to the input, if it is a
+// MetaError or a TemplateError; other errors are returned as-is.
+func (sm *sourceMap) mapError(err error) error {
+
+	switch e := err.(type) {
+	case MetaError:
+		e.Line = sm.line(e.Line)
+		return e
+	case TemplateError:
+		e.Line = sm.line(e.Line)
+		return e
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+// tables.go - structured data from Markdown tables.
+
+package frostedmd
+
+import (
+	// Third-party:
+	"gopkg.in/russross/blackfriday.v1"
+)
+
+// Table describes a Markdown table extracted as structured data.  Tables
+// are only extracted if the Parser's ExtractTables option is set.
+type Table struct {
+	Headers []TableCell   `json:"headers"`
+	Rows    [][]TableCell `json:"rows"`
+	Align   []string      `json:"align"` // "left", "right", "center" or ""
+}
+
+// TableCell describes a single cell in a Table, in both plain-text and
+// rendered-HTML form.
+type TableCell struct {
+	Text string `json:"text"`
+	HTML string `json:"html"`
+}
+
+// newTableCell returns a TableCell for the given rendered HTML.
+func newTableCell(html []byte) TableCell {
+	return TableCell{
+		Text: plainText(html),
+		HTML: string(html),
+	}
+}
+
+// tableAlign converts blackfriday column data to alignment strings.
+func tableAlign(columnData []int) []string {
+
+	align := make([]string, len(columnData))
+	for i, flags := range columnData {
+		switch flags {
+		case blackfriday.TABLE_ALIGNMENT_LEFT:
+			align[i] = "left"
+		case blackfriday.TABLE_ALIGNMENT_RIGHT:
+			align[i] = "right"
+		case blackfriday.TABLE_ALIGNMENT_CENTER:
+			align[i] = "center"
+		}
+	}
+	return align
+}
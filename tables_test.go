@@ -0,0 +1,88 @@
+// tables_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Parse_ExtractTables(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# Pricing
+
+| Plan     | Price  | Notes            |
+|:---------|-------:|:----------------:|
+| *Basic*  | $10    | "cheap" & cheerful |
+| Pro      | $20    |                  |
+
+Another:
+
+| A | B |
+|---|---|
+| 1 | 2 |
+`
+	exp := []*frostedmd.Table{
+		{
+			Headers: []frostedmd.TableCell{
+				{Text: "Plan", HTML: "Plan"},
+				{Text: "Price", HTML: "Price"},
+				{Text: "Notes", HTML: "Notes"},
+			},
+			Rows: [][]frostedmd.TableCell{
+				{
+					{Text: "Basic", HTML: "<em>Basic</em>"},
+					{Text: "$10", HTML: "$10"},
+					{Text: "“cheap” & cheerful",
+						HTML: "&ldquo;cheap&rdquo; &amp; cheerful"},
+				},
+				{
+					{Text: "Pro", HTML: "Pro"},
+					{Text: "$20", HTML: "$20"},
+					{Text: "", HTML: ""},
+				},
+			},
+			Align: []string{"left", "right", "center"},
+		},
+		{
+			Headers: []frostedmd.TableCell{
+				{Text: "A", HTML: "A"},
+				{Text: "B", HTML: "B"},
+			},
+			Rows: [][]frostedmd.TableCell{
+				{
+					{Text: "1", HTML: "1"},
+					{Text: "2", HTML: "2"},
+				},
+			},
+			Align: []string{"", ""},
+		},
+	}
+
+	parser := frostedmd.New()
+	parser.ExtractTables = true
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(exp, res.Tables, "tables as expected")
+	assert.Contains(string(res.Content), "<table>", "tables still rendered")
+
+}
+
+func Test_Parse_ExtractTables_Off(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "| A | B |\n|---|---|\n| 1 | 2 |\n"
+
+	res, err := frostedmd.New().Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Nil(res.Tables, "no tables by default")
+
+}
@@ -0,0 +1,55 @@
+// template.go - rendering parse results through html/template.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"bytes"
+	"errors"
+	"html/template"
+)
+
+// TemplateMetaKey is the Meta key with which a document may specify the
+// name of the template to be used by RenderTemplate.
+const TemplateMetaKey = "Template"
+
+// TemplateData is the data made available to templates by RenderTemplate.
+// File is nil unless the ParseResult has it set.
+type TemplateData struct {
+	Meta     map[string]interface{}
+	Content  template.HTML
+	Headings []Heading
+	File     *FileInfo
+}
+
+// NewTemplateData returns the TemplateData for res.  Note that the Content
+// is trusted to be safe HTML.
+func NewTemplateData(res *ParseResult) *TemplateData {
+	return &TemplateData{
+		Meta:     res.Meta,
+		Content:  template.HTML(res.Content),
+		Headings: res.Headings,
+		File:     res.File,
+	}
+}
+
+// RenderTemplate executes tmpl with the TemplateData for res, and returns
+// the output.  If the Meta of res names a template under TemplateMetaKey,
+// the template of that name associated with tmpl is executed instead; it
+// is an error if there is no such template.
+func RenderTemplate(tmpl *template.Template, res *ParseResult) ([]byte, error) {
+
+	if name := metaString(res.Meta, TemplateMetaKey); name != "" {
+		named := tmpl.Lookup(name)
+		if named == nil {
+			return nil, errors.New("Template not found: " + name)
+		}
+		tmpl = named
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, NewTemplateData(res)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
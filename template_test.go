@@ -0,0 +1,93 @@
+// template_test.go
+
+package frostedmd_test
+
+import (
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Parse_Headings(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# One & Only {#one}\n\n> ## Quoted\n\n### *Three*\n"
+	exp := []frostedmd.Heading{
+		{Level: 1, ID: "one", Text: "One & Only"},
+		{Level: 2, Text: "Quoted"},
+		{Level: 3, Text: "Three"},
+	}
+
+	res, err := frostedmd.New().Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(exp, res.Headings, "headings as expected")
+
+}
+
+func Test_RenderTemplate(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tmpl := template.Must(template.New("page").Parse(
+		`<title>{{ .Meta.Name }}</title>
+{{ range .Headings }}<a href="#{{ .ID }}">{{ .Text }}</a>
+{{ end }}{{ with .File }}{{ .Name }} {{ .ModTime.Year }}
+{{ end }}{{ .Content }}`))
+
+	res, err := frostedmd.New().Parse([]byte(
+		"# A < B {#ab}\n\n    Name: A & B\n\nHere."))
+	if !assert.Nil(err, "no parse error") {
+		return
+	}
+	res.File = &frostedmd.FileInfo{
+		Path:    "docs/ab.md",
+		Name:    "ab.md",
+		ModTime: time.Date(2016, 12, 30, 0, 0, 0, 0, time.UTC),
+	}
+	exp := `<title>A &amp; B</title>
+<a href="#ab">A &lt; B</a>
+ab.md 2016
+<h1 id="ab">A &lt; B</h1>
+
+<p>Here.</p>
+`
+
+	out, err := frostedmd.RenderTemplate(tmpl, res)
+	assert.Nil(err, "no error returned")
+	assert.Equal(exp, string(out), "output as expected")
+
+}
+
+func Test_RenderTemplate_Named(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tmpl := template.Must(template.New("page").Parse(`page`))
+	template.Must(tmpl.New("special").Parse(`special {{ .Meta.Foo }}`))
+
+	res, _ := frostedmd.New().Parse([]byte(
+		"    Template: special\n    Foo: bar\n\nHere."))
+	out, err := frostedmd.RenderTemplate(tmpl, res)
+	assert.Nil(err, "no error returned")
+	assert.Equal("special bar", string(out), "named template used")
+
+	res, _ = frostedmd.New().Parse([]byte("    Template: nope\n\nHere."))
+	_, err = frostedmd.RenderTemplate(tmpl, res)
+	if assert.Error(err, "error returned") {
+		assert.Equal("Template not found: nope", err.Error(),
+			"error useful")
+	}
+
+	res, _ = frostedmd.New().Parse([]byte("    Foo: x\n\nHere."))
+	_, err = frostedmd.RenderTemplate(
+		template.Must(template.New("x").Parse(`{{ .Meta.Foo.Bar }}`)),
+		res)
+	assert.Error(err, "execution error returned")
+
+}
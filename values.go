@@ -0,0 +1,180 @@
+// values.go - working with Meta values of unknown type.
+//
+// Meta values come from JSON or YAML, and so may be strings, numbers of
+// various types, booleans, lists, maps, or (for file-derived meta) times.
+// Dates are usually strings.  These helpers try to do the expected thing
+// with all of them.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayouts are the layouts tried, in order, when a string Meta value is
+// interpreted as a date.
+var DateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// metaValue returns the value for key in mm, which may also be given in
+// all-lowercase or all-uppercase.  A key containing dots is also looked up
+// as a path into nested maps, e.g. "File.Path".  If there is no such value,
+// nil is returned.
+func metaValue(mm map[string]interface{}, key string) interface{} {
+
+	keys := []string{key, strings.ToLower(key), strings.ToUpper(key)}
+	for _, k := range keys {
+		if v, ok := mm[k]; ok && v != nil {
+			return v
+		}
+	}
+	if dot := strings.IndexByte(key, '.'); dot > 0 {
+		switch sub := metaValue(mm, key[:dot]).(type) {
+		case map[string]interface{}:
+			return metaValue(sub, key[dot+1:])
+		case map[interface{}]interface{}:
+			return metaValue(stringKeys(sub), key[dot+1:])
+		}
+	}
+	return nil
+}
+
+// stringKeys converts a map as decoded by YAML to one with string keys.
+func stringKeys(m map[interface{}]interface{}) map[string]interface{} {
+
+	sm := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		sm[fmt.Sprint(k)] = v
+	}
+	return sm
+}
+
+// metaList returns the value as a list: lists are returned as-is, nil as
+// an empty list, and anything else as a single-item list.
+func metaList(v interface{}) []interface{} {
+
+	switch v := v.(type) {
+	case nil:
+		return []interface{}{}
+	case []interface{}:
+		return v
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	}
+	return []interface{}{v}
+}
+
+// metaTime interprets the value as a time, if possible.
+func metaTime(v interface{}) (time.Time, bool) {
+
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range DateLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// metaNumber interprets the value as a number, if possible.  Strings are
+// not interpreted.
+func metaNumber(v interface{}) (float64, bool) {
+
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// compareValues compares two values, returning -1, 0 or 1.  Numbers are
+// compared numerically and times chronologically, including strings that
+// can be parsed as numbers or dates if the other value is of that kind.
+// Everything else is compared by its string representation.  Nil values
+// are greater than everything else, thus sort last.
+func compareValues(a, b interface{}) int {
+
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	if an, ok := metaNumber(a); ok {
+		if bn, ok := toNumber(b); ok {
+			return compareFloats(an, bn)
+		}
+	} else if bn, ok := metaNumber(b); ok {
+		if an, ok := toNumber(a); ok {
+			return compareFloats(an, bn)
+		}
+	}
+	if at, ok := metaTime(a); ok {
+		if bt, ok := metaTime(b); ok {
+			switch {
+			case at.Before(bt):
+				return -1
+			case at.After(bt):
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// toNumber converts the value to a number, including strings.
+func toNumber(v interface{}) (float64, bool) {
+
+	if n, ok := metaNumber(v); ok {
+		return n, true
+	}
+	if s, ok := v.(string); ok {
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// compareFloats compares two numbers, returning -1, 0 or 1.
+func compareFloats(a, b float64) int {
+
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
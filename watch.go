@@ -0,0 +1,177 @@
+// watch.go - watching a file tree for changes.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"context"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is the interval at which a Watcher polls for changes
+// if its Interval is not set.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// DefaultWatchDebounce is the time a Watcher waits for further changes
+// before reporting them, if its Debounce is not set.
+const DefaultWatchDebounce = 200 * time.Millisecond
+
+// Watcher watches the files in a filesystem for changes by polling it,
+// which works the same everywhere, including on network filesystems and
+// with any fs.FS.  Hidden files and directories, whose names begin with a
+// dot, are ignored: editors tend to keep their temporary files there.
+//
+// Changes are reported in batches, once no further changes have been seen
+// for the Debounce time, so that a flurry of saves is handled only once.
+type Watcher struct {
+	FS       fs.FS
+	Interval time.Duration
+	Debounce time.Duration
+	files    map[string]watchState
+}
+
+// watchState is what the Watcher knows of a file.
+type watchState struct {
+	size    int64
+	modTime time.Time
+}
+
+// WatchEvent is a batch of changes reported by a Watcher: the names of the
+// files modified, added or removed, in lexical order.  If the filesystem
+// could not be read, Err is set instead.
+type WatchEvent struct {
+	Changed []string
+	Err     error
+}
+
+// NewWatcher returns a Watcher for fsys with the default settings.
+func NewWatcher(fsys fs.FS) *Watcher {
+	return &Watcher{FS: fsys}
+}
+
+// Poll returns the names of all files that have changed since the last
+// call, in lexical order.  The first call only records the current state,
+// and returns nothing.
+func (w *Watcher) Poll() ([]string, error) {
+
+	files := map[string]watchState{}
+	err := fs.WalkDir(w.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[name] = watchState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	first := w.files == nil
+	changed := []string{}
+	for name, state := range files {
+		if prev, ok := w.files[name]; !ok || prev.size != state.size ||
+			!prev.modTime.Equal(state.modTime) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range w.files {
+		if _, ok := files[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	w.files = files
+	if first {
+		return nil, nil
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// Watch polls for changes until the context is done, sending them on the
+// returned channel, which is closed at the end.  Changes already made
+// before Watch is called are not reported, unless Poll was called before.
+// Errors are reported as they happen, and do not stop the Watcher.
+func (w *Watcher) Watch(ctx context.Context) <-chan WatchEvent {
+
+	interval, debounce := w.Interval, w.Debounce
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+	events := make(chan WatchEvent)
+
+	go func() {
+		defer close(events)
+		send := func(ev WatchEvent) bool {
+			select {
+			case events <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if w.files == nil {
+			if _, err := w.Poll(); err != nil && !send(WatchEvent{Err: err}) {
+				return
+			}
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		pending := map[string]bool{}
+		var last time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				changed, err := w.Poll()
+				if err != nil {
+					if !send(WatchEvent{Err: err}) {
+						return
+					}
+					continue
+				}
+				if len(changed) > 0 {
+					for _, name := range changed {
+						pending[name] = true
+					}
+					last = now
+					continue
+				}
+				if len(pending) == 0 || now.Sub(last) < debounce {
+					continue
+				}
+				batch := []string{}
+				for name := range pending {
+					batch = append(batch, name)
+				}
+				sort.Strings(batch)
+				pending = map[string]bool{}
+				if !send(WatchEvent{Changed: batch}) {
+					return
+				}
+			}
+		}
+	}()
+
+	return events
+}
@@ -0,0 +1,98 @@
+// watch_test.go
+
+package frostedmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Watcher_Poll(t *testing.T) {
+
+	assert := assert.New(t)
+
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"a.md":          {Data: []byte("A"), ModTime: now},
+		"b.md":          {Data: []byte("B"), ModTime: now},
+		".a.md.swp":     {Data: []byte("swap"), ModTime: now},
+		".git/config":   {Data: []byte("git"), ModTime: now},
+		"sub/c.md":      {Data: []byte("C"), ModTime: now},
+		"sub/image.png": {Data: []byte("PNG"), ModTime: now},
+	}
+	w := frostedmd.NewWatcher(fsys)
+
+	changed, err := w.Poll()
+	assert.Nil(err, "no error on first poll")
+	assert.Empty(changed, "nothing changed on first poll")
+
+	changed, err = w.Poll()
+	assert.Nil(err, "no error on second poll")
+	assert.Empty(changed, "nothing changed on second poll")
+
+	fsys["a.md"] = &fstest.MapFile{Data: []byte("A"), ModTime: now.Add(1)}
+	fsys["sub/c.md"] = &fstest.MapFile{Data: []byte("CC"), ModTime: now}
+	fsys["d.md"] = &fstest.MapFile{Data: []byte("D"), ModTime: now}
+	fsys[".a.md.swp"] = &fstest.MapFile{Data: []byte("new"), ModTime: now}
+	delete(fsys, "b.md")
+	changed, err = w.Poll()
+	assert.Nil(err, "no error after changes")
+	assert.Equal([]string{"a.md", "b.md", "d.md", "sub/c.md"}, changed,
+		"modified, removed and added files, but not hidden ones")
+
+	w = frostedmd.NewWatcher(os.DirFS(filepath.Join(t.TempDir(), "nope")))
+	_, err = w.Poll()
+	assert.Error(err, "error for missing directory")
+
+}
+
+func Test_Watcher_Watch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.md", "A")
+
+	w := frostedmd.NewWatcher(os.DirFS(dir))
+	w.Interval = 5 * time.Millisecond
+	w.Debounce = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := w.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	events := w.Watch(ctx)
+
+	// Several quick saves make a single batch.
+	write("a.md", "AA")
+	write("b.md", "B")
+	time.Sleep(8 * time.Millisecond)
+	write("a.md", "AAA")
+
+	select {
+	case ev := <-events:
+		assert.Nil(ev.Err, "no error")
+		assert.Equal([]string{"a.md", "b.md"}, ev.Changed, "one batch")
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+
+	cancel()
+	for range events {
+	}
+
+}
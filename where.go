@@ -0,0 +1,310 @@
+// where.go - filter expressions over Meta values.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Where compiles a filter expression into a Predicate.  Expressions compare
+// Meta values to literals, and may be combined with and, or, not and
+// parentheses, for example:
+//
+//	Tags contains "golang" and Date > 2024-01-01
+//	not (Draft or Weight >= 10)
+//
+// The left side of a comparison is a Meta key, looked up as by Collection
+// (thus "File.Path" reaches into nested maps); the right side a literal,
+// which may be quoted.  Unquoted literals that look like numbers are
+// numbers, and true, false and null have their usual meanings.  The
+// comparison operators are =, !=, <, <=, >, >= and contains, where the
+// latter matches list items or substrings; values are compared as numbers,
+// dates or strings per their types.  A missing key never matches any
+// comparison, but may be tested against null.  A key by itself matches if
+// it is present and not false, zero, or empty.
+func Where(expr string) (Predicate, error) {
+
+	tokens, err := lexWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+	wp := &whereParser{tokens: tokens}
+	pred, err := wp.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if wp.pos < len(wp.tokens) {
+		return nil, fmt.Errorf("Unexpected %q in expression.",
+			wp.tokens[wp.pos].text)
+	}
+	return pred, nil
+}
+
+// whereToken is a token in a filter expression.
+type whereToken struct {
+	text   string
+	quoted bool
+}
+
+// whereOperators are the comparison operators, longest first.
+var whereOperators = []string{"<=", ">=", "!=", "==", "=", "<", ">"}
+
+// lexWhere splits a filter expression into tokens.
+func lexWhere(expr string) ([]whereToken, error) {
+
+	tokens := []whereToken{}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, whereToken{text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, errors.New("Unterminated string in expression.")
+			}
+			tokens = append(tokens, whereToken{
+				text:   expr[i+1 : i+1+end],
+				quoted: true,
+			})
+			i += end + 2
+		default:
+			op := ""
+			for _, o := range whereOperators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op != "" {
+				tokens = append(tokens, whereToken{text: op})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\n()\"'=!<>",
+				rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, whereToken{text: expr[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+// whereParser is a recursive-descent parser for filter expressions.
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+// peek returns the next unquoted token's text, or the empty string.
+func (wp *whereParser) peek() string {
+
+	if wp.pos >= len(wp.tokens) || wp.tokens[wp.pos].quoted {
+		return ""
+	}
+	return wp.tokens[wp.pos].text
+}
+
+// keyword returns true and advances if the next token is the keyword.
+func (wp *whereParser) keyword(kw string) bool {
+
+	if strings.EqualFold(wp.peek(), kw) {
+		wp.pos++
+		return true
+	}
+	return false
+}
+
+func (wp *whereParser) parseOr() (Predicate, error) {
+
+	left, err := wp.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for wp.keyword("or") {
+		right, err := wp.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(res *ParseResult) bool { return l(res) || right(res) }
+	}
+	return left, nil
+}
+
+func (wp *whereParser) parseAnd() (Predicate, error) {
+
+	left, err := wp.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for wp.keyword("and") {
+		right, err := wp.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(res *ParseResult) bool { return l(res) && right(res) }
+	}
+	return left, nil
+}
+
+func (wp *whereParser) parseNot() (Predicate, error) {
+
+	if wp.keyword("not") {
+		pred, err := wp.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(res *ParseResult) bool { return !pred(res) }, nil
+	}
+	return wp.parseComparison()
+}
+
+func (wp *whereParser) parseComparison() (Predicate, error) {
+
+	if wp.pos >= len(wp.tokens) {
+		return nil, errors.New("Unexpected end of expression.")
+	}
+	if wp.keyword("(") {
+		pred, err := wp.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !wp.keyword(")") {
+			return nil, errors.New("Missing ) in expression.")
+		}
+		return pred, nil
+	}
+
+	key := wp.tokens[wp.pos]
+	if key.quoted || !isWhereKey(key.text) {
+		return nil, fmt.Errorf("Expected a key, found %q.", key.text)
+	}
+	wp.pos++
+
+	op := strings.ToLower(wp.peek())
+	switch op {
+	case "=", "==", "!=", "<", "<=", ">", ">=", "contains":
+		wp.pos++
+	default:
+		return func(res *ParseResult) bool {
+			return truthy(metaValue(res.Meta, key.text))
+		}, nil
+	}
+
+	if wp.pos >= len(wp.tokens) || wp.peek() == "(" || wp.peek() == ")" {
+		return nil, fmt.Errorf("Expected a value after %s.", op)
+	}
+	value := whereLiteral(wp.tokens[wp.pos])
+	wp.pos++
+
+	if op == "contains" {
+		contains := Contains(key.text, value)
+		return func(res *ParseResult) bool {
+			if value == nil {
+				return false
+			}
+			return contains(res)
+		}, nil
+	}
+	return func(res *ParseResult) bool {
+		v := metaValue(res.Meta, key.text)
+		if value == nil {
+			switch op {
+			case "=", "==":
+				return v == nil
+			case "!=":
+				return v != nil
+			}
+			return false
+		}
+		if v == nil {
+			return false
+		}
+		cmp := compareValues(v, value)
+		switch op {
+		case "!=":
+			return cmp != 0
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		}
+		return cmp == 0
+	}, nil
+}
+
+// isWhereKey returns true if the text is usable as a Meta key.
+func isWhereKey(text string) bool {
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) &&
+			r != '_' && r != '-' && r != '.' {
+			return false
+		}
+	}
+	switch strings.ToLower(text) {
+	case "", "and", "or", "not", "contains":
+		return false
+	}
+	return true
+}
+
+// whereLiteral returns the value of a literal token.
+func whereLiteral(tok whereToken) interface{} {
+
+	if tok.quoted {
+		return tok.text
+	}
+	switch tok.text {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(tok.text, 64); err == nil {
+		return n
+	}
+	return tok.text
+}
+
+// truthy returns true if the value is present and not false, zero or
+// empty.
+func truthy(v interface{}) bool {
+
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	case map[interface{}]interface{}:
+		return len(v) > 0
+	}
+	if n, ok := metaNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
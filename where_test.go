@@ -0,0 +1,84 @@
+// where_test.go
+
+package frostedmd_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Where(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res := &frostedmd.ParseResult{Meta: map[string]interface{}{
+		"Title":  "Frosted Markdown",
+		"Tags":   []interface{}{"golang", "markdown"},
+		"Date":   "2024-02-03",
+		"Weight": 5,
+		"Draft":  false,
+		"File":   map[interface{}]interface{}{"Path": "a/b.md"},
+	}}
+
+	for expr, exp := range map[string]bool{
+		`Tags contains "golang"`:                         true,
+		`Tags contains rust`:                             false,
+		`Title contains 'Markdown'`:                      true,
+		`Tags contains "golang" and Date > 2024-01-01`:   true,
+		`Tags contains "golang" and Date > 2024-03`:      false,
+		`Date >= 2024-02-03 and Date <= "2024-02-03"`:    true,
+		`Weight > 4.5 and Weight < 10`:                   true,
+		`Weight = 5 and Weight == "5" and Weight != 6`:   true,
+		`Title = "Frosted Markdown"`:                     true,
+		`Title != "Frosted Markdown"`:                    false,
+		`Draft`:                                          false,
+		`not Draft`:                                      true,
+		`Draft = false`:                                  true,
+		`Tags`:                                           true,
+		`Missing`:                                        false,
+		`Missing = null and Title != null`:               true,
+		`Missing != 1`:                                   false,
+		`Missing < 1 or Missing > 1`:                     false,
+		`Draft or Weight >= 5`:                           true,
+		`not (Draft or Weight >= 5)`:                     false,
+		`Title = "Frosted Markdown" AND NOT Draft`:       true,
+		`File.Path = "a/b.md"`:                           true,
+		`(Weight > 1 or Draft) and (Tags contains "go")`: false,
+	} {
+		pred, err := frostedmd.Where(expr)
+		if assert.Nil(err, "no error for %s", expr) {
+			assert.Equal(exp, pred(res), "result for %s", expr)
+		}
+	}
+
+}
+
+func Test_Where_Errors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for expr, exp := range map[string]string{
+		``:                    "Unexpected end of expression.",
+		`Title =`:             "Expected a value after =.",
+		`Title = (`:           "Expected a value after =.",
+		`"Title" = x`:         `Expected a key, found "Title".`,
+		`and`:                 `Expected a key, found "and".`,
+		`(Title = x`:          "Missing ) in expression.",
+		`Title = x y`:         `Unexpected "y" in expression.`,
+		`Title = "x`:          "Unterminated string in expression.",
+		`Title = x and`:       "Unexpected end of expression.",
+		`not`:                 "Unexpected end of expression.",
+		`Title = x or (Tags`:  "Missing ) in expression.",
+		`Title = x or = 1`:    `Expected a key, found "=".`,
+		`(Title = x) and = 1`: `Expected a key, found "=".`,
+	} {
+		_, err := frostedmd.Where(expr)
+		if assert.Error(err, "error for %s", expr) {
+			assert.Equal(exp, err.Error(), "error message for %s", expr)
+		}
+	}
+
+}
@@ -0,0 +1,118 @@
+// wiki.go - wiki-style links between pages.
+
+package frostedmd
+
+import (
+	// Standard Library:
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Resolver maps wiki page names to URLs.  If the page does not exist, ok
+// is false.
+type Resolver interface {
+	Resolve(name string) (url string, ok bool)
+}
+
+// ResolverFunc is a function usable as a Resolver.
+type ResolverFunc func(name string) (string, bool)
+
+// Resolve calls the function per the Resolver interface.
+func (f ResolverFunc) Resolve(name string) (string, bool) {
+	return f(name)
+}
+
+// SlugResolver is the default Resolver, which maps page names to slugs,
+// e.g. "Deploy Guide" to "deploy-guide.html".  If Pages is not nil, only
+// the slugs it contains are resolved; otherwise every page is assumed to
+// exist.
+type SlugResolver struct {
+	Prefix string          // prepended to every URL, e.g. "/wiki/"
+	Pages  map[string]bool // the known slugs
+}
+
+// Resolve returns the URL for the named page per the Resolver interface.
+func (r SlugResolver) Resolve(name string) (string, bool) {
+
+	slug := Slugify(name)
+	if slug == "" || (r.Pages != nil && !r.Pages[slug]) {
+		return "", false
+	}
+	return r.Prefix + slug + ".html", true
+}
+
+// Slugify converts a name to a slug suitable for use in a URL: lower-case
+// letters and digits, with every other run of characters replaced by a
+// single hyphen.
+func Slugify(name string) string {
+
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// wikiLinkRegexp matches [[Page Name]] and [[Page Name|link text]].
+var wikiLinkRegexp = regexp.MustCompile(
+	`\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]+))?\]\]`)
+
+// expandWikiLinks replaces the wiki links in the input with placeholders
+// for their rendered links, returning the resulting source and a warning
+// for every link that could not be resolved.  Links within code blocks and
+// code spans, and links escaped with a backslash, are left alone.  Warning
+// lines are those of the original source per sm.
+func (p *Parser) expandWikiLinks(input []byte, ph *placeholders, sm *sourceMap) (*sourceBuffer, []string) {
+
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = SlugResolver{}
+	}
+
+	out := &sourceBuffer{}
+	var warnings []string
+	pos := 0
+	for _, r := range textRanges(input) {
+		text := input[r[0]:r[1]]
+		for _, m := range wikiLinkRegexp.FindAllSubmatchIndex(text, -1) {
+			start, end := r[0]+m[0], r[0]+m[1]
+			if start > 0 && input[start-1] == '\\' {
+				continue
+			}
+			name := strings.TrimSpace(string(text[m[2]:m[3]]))
+			label := name
+			if m[4] >= 0 {
+				label = strings.TrimSpace(string(text[m[4]:m[5]]))
+			}
+			var link string
+			if url, ok := resolver.Resolve(name); ok {
+				link = fmt.Sprintf(`<a href="%s">%s</a>`,
+					html.EscapeString(url), html.EscapeString(label))
+			} else {
+				link = fmt.Sprintf(`<a class="missing">%s</a>`,
+					html.EscapeString(label))
+				line, _ := sm.position(start)
+				warnings = append(warnings, fmt.Sprintf(
+					"Unresolved wiki link on line %d: %s", line, name))
+			}
+			out.copy(input, pos, start)
+			out.insert([]byte(ph.add(link)), start)
+			pos = end
+		}
+	}
+	out.copy(input, pos, len(input))
+
+	return out, warnings
+}
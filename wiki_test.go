@@ -0,0 +1,125 @@
+// wiki_test.go
+
+package frostedmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/frostedmd"
+)
+
+func Test_Slugify(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for in, exp := range map[string]string{
+		"Deploy Guide":         "deploy-guide",
+		"  What's New? (2024)": "what-s-new-2024",
+		"Ünïcode Straße":       "ünïcode-straße",
+		"---":                  "",
+	} {
+		assert.Equal(exp, frostedmd.Slugify(in), "slug for "+in)
+	}
+
+}
+
+func Test_Parse_WikiLinks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# Wiki
+
+See [[Deploy Guide]] and [[Deploy Guide|see *here*]], but not
+` + "`[[Code Page]]`" + ` nor \[[Escaped Page]].
+
+` + "```" + `
+[[Fenced Page]]
+` + "```" + `
+`
+	expContent := `<h1>Wiki</h1>
+
+<p>See <a href="deploy-guide.html">Deploy Guide</a> and <a href="deploy-guide.html">see *here*</a>, but not
+<code>[[Code Page]]</code> nor [[Escaped Page]].</p>
+
+<pre><code>[[Fenced Page]]
+</code></pre>
+`
+
+	parser := frostedmd.New()
+	parser.WikiLinks = true
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(expContent, string(res.Content), "content as expected")
+	assert.Nil(res.Warnings, "no warnings")
+
+}
+
+func Test_Parse_WikiLinks_Missing(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := `# Wiki
+
+* [[Deploy Guide]]
+* [[Missing Page|Nowhere]]
+`
+	expContent := `<h1>Wiki</h1>
+
+<ul>
+<li><a href="/wiki/deploy-guide.html">Deploy Guide</a></li>
+<li><a class="missing">Nowhere</a></li>
+</ul>
+`
+
+	parser := frostedmd.New()
+	parser.WikiLinks = true
+	parser.Resolver = frostedmd.SlugResolver{
+		Prefix: "/wiki/",
+		Pages:  map[string]bool{"deploy-guide": true},
+	}
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(expContent, string(res.Content), "content as expected")
+	assert.Equal([]string{"Unresolved wiki link on line 4: Missing Page"},
+		res.Warnings, "warning for missing page")
+
+}
+
+func Test_Parse_WikiLinks_ResolverFunc(t *testing.T) {
+
+	assert := assert.New(t)
+
+	parser := frostedmd.New()
+	parser.WikiLinks = true
+	parser.Resolver = frostedmd.ResolverFunc(func(name string) (string, bool) {
+		return "/pages?q=" + strings.ToUpper(name), true
+	})
+	res, err := parser.Parse([]byte("Go to [[Home & Away]]."))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal(`<p>Go to <a href="/pages?q=HOME &amp; AWAY">Home &amp; Away</a>.</p>`+
+		"\n", string(res.Content), "content as expected")
+
+}
+
+func Test_Parse_WikiLinks_Title(t *testing.T) {
+
+	assert := assert.New(t)
+
+	input := "# [[Page]]\n\n    Tags: [a]\n\nText.\n"
+
+	parser := frostedmd.New()
+	parser.WikiLinks = true
+	res, err := parser.Parse([]byte(input))
+
+	assert.Nil(err, "no error returned")
+	assert.Equal("Page", res.Meta["Title"], "title from link text")
+	assert.Equal(`<h1><a href="page.html">Page</a></h1>`,
+		strings.SplitN(string(res.Content), "\n", 2)[0], "link in heading")
+
+}